@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// maxAdmissionReview bounds the size of an AdmissionReview we are willing
+// to read.
+const maxAdmissionReview = 3 << 20
+
+// validateWebsite runs the checks of the syncHandler on website, for
+// manifests validated before the controller sees them.
+func validateWebsite(website *myv1alpha1.Website) error {
+	if website.Spec.DeploymentName == "" {
+		return fmt.Errorf("spec.deploymentName: must be specified")
+	}
+	for _, validate := range []func(*myv1alpha1.Website) error{
+		validateSource,
+		validatePaths,
+		func(w *myv1alpha1.Website) error { return validateServer(w.Spec.Server) },
+		func(w *myv1alpha1.Website) error { return validateAuth(w.Spec.Auth) },
+		validateScheduling,
+		validatePodSecurity,
+		validateNetworkPolicy,
+		validateAvailability,
+		validatePodTemplateOverrides,
+		validateServiceType,
+		validateStrategy,
+	} {
+		if err := validate(website); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateWebsitePolicySpec returns an error when a policy holds a
+// malformed URL pattern or an unknown Service type.
+func validateWebsitePolicySpec(spec *myv1alpha1.WebsitePolicySpec) error {
+	if _, err := compileURLPatterns(spec.AllowedURLPatterns); err != nil {
+		return fmt.Errorf("spec.%v", err)
+	}
+	for i, serviceType := range spec.AllowedServiceTypes {
+		switch serviceType {
+		case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+		default:
+			return fmt.Errorf("spec.allowedServiceTypes[%d]: unsupported type %q", i, serviceType)
+		}
+	}
+	return nil
+}
+
+// admissionHandler is the validating admission webhook of Websites,
+// ClusterWebsites, their policies and templates. Websites are denied when
+// they are invalid, take the deployment, service or host of another website
+// or violate the policies of their namespace, evaluated from the caches of
+// the controller.
+type admissionHandler struct {
+	controller *Controller
+}
+
+func (h *admissionHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxAdmissionReview))
+	if err != nil {
+		http.Error(w, "unable to read admission review", http.StatusBadRequest)
+		return
+	}
+	review := &admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "malformed admission review", http.StatusBadRequest)
+		return
+	}
+	if !h.controller.cachesSynced() {
+		http.Error(w, "informer caches not synced yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	response := &admissionv1beta1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	if err := h.admit(review.Request); err != nil {
+		klog.V(2).Infof("Denied %s of %s %s/%s: %v", review.Request.Operation, review.Request.Kind.Kind, review.Request.Namespace, review.Request.Name, err)
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonForbidden,
+			Code:    http.StatusForbidden,
+			Message: err.Error(),
+		}
+	}
+	review.Response = response
+	review.Request = nil
+	out, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+// admit returns why the object of req is denied, nil to admit it.
+func (h *admissionHandler) admit(req *admissionv1beta1.AdmissionRequest) error {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return nil
+	}
+	switch req.Kind.Kind {
+	case "Website":
+		website := &myv1alpha1.Website{}
+		if err := json.Unmarshal(req.Object.Raw, website); err != nil {
+			return err
+		}
+		if website.Namespace == "" {
+			website.Namespace = req.Namespace
+		}
+		// A template created after the website is applied when it exists,
+		// until then the website is checked as it is
+		if effective, _, err := h.controller.resolveWebsite(website); err == nil {
+			website = effective
+		}
+		if err := validateWebsite(website); err != nil {
+			return err
+		}
+		var old *myv1alpha1.Website
+		if req.Operation == admissionv1beta1.Update {
+			old = &myv1alpha1.Website{}
+			if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+				return err
+			}
+			if effective, _, err := h.controller.resolveWebsite(old); err == nil {
+				old = effective
+			}
+		}
+		if err := h.controller.admitUnique(website, old); err != nil {
+			return err
+		}
+		return h.controller.admitWebsite(website, old)
+	case "ClusterWebsite":
+		clusterWebsite := &myv1alpha1.ClusterWebsite{}
+		if err := json.Unmarshal(req.Object.Raw, clusterWebsite); err != nil {
+			return err
+		}
+		if clusterWebsite.Spec.TargetNamespace == "" {
+			return fmt.Errorf(MessageMissingTargetNamespace)
+		}
+		// Not held to the policies, ClusterWebsites are owned by the
+		// platform team
+		website := clusterWebsiteView(clusterWebsite)
+		if effective, _, err := h.controller.resolveWebsite(website); err == nil {
+			website = effective
+		}
+		if err := validateWebsite(website); err != nil {
+			return err
+		}
+		var old *myv1alpha1.Website
+		if req.Operation == admissionv1beta1.Update {
+			oldClusterWebsite := &myv1alpha1.ClusterWebsite{}
+			if err := json.Unmarshal(req.OldObject.Raw, oldClusterWebsite); err != nil {
+				return err
+			}
+			old = clusterWebsiteView(oldClusterWebsite)
+			if effective, _, err := h.controller.resolveWebsite(old); err == nil {
+				old = effective
+			}
+		}
+		return h.controller.admitUnique(website, old)
+	case "WebsitePreview":
+		preview := &myv1alpha1.WebsitePreview{}
+		if err := json.Unmarshal(req.Object.Raw, preview); err != nil {
+			return err
+		}
+		if preview.Namespace == "" {
+			preview.Namespace = req.Namespace
+		}
+		return h.controller.admitPreview(preview)
+	case "WebsiteTemplate":
+		template := &myv1alpha1.WebsiteTemplate{}
+		if err := json.Unmarshal(req.Object.Raw, template); err != nil {
+			return err
+		}
+		return validateWebsiteTemplate(template)
+	case "WebsitePolicy":
+		policy := &myv1alpha1.WebsitePolicy{}
+		if err := json.Unmarshal(req.Object.Raw, policy); err != nil {
+			return err
+		}
+		return validateWebsitePolicySpec(&policy.Spec)
+	case "ClusterWebsitePolicy":
+		policy := &myv1alpha1.ClusterWebsitePolicy{}
+		if err := json.Unmarshal(req.Object.Raw, policy); err != nil {
+			return err
+		}
+		if policy.Spec.NamespaceSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector); err != nil {
+				return fmt.Errorf("spec.namespaceSelector: %v", err)
+			}
+		}
+		return validateWebsitePolicySpec(&policy.Spec.WebsitePolicySpec)
+	}
+	return nil
+}
+
+// admitWebsite returns an error listing the policy violations of website,
+// old being the website it updates. A new website comes after the existing
+// ones of its namespace, so it is denied the replicas they already hold. An
+// update not raising the replicas is not held to the replica limits, so a
+// website over them can always be scaled down.
+func (c *Controller) admitWebsite(website, old *myv1alpha1.Website) error {
+	policies, err := c.websitePolicies(website.Namespace)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	if old != nil && websiteReplicas(website) <= websiteReplicas(old) {
+		for i := range policies {
+			spec := policies[i].spec.DeepCopy()
+			spec.MaxReplicas, spec.MaxNamespaceReplicas = nil, nil
+			policies[i].spec = spec
+		}
+	}
+	others, err := c.websitesLister.Websites(website.Namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	if violations := checkWebsitePolicies(website, c.resolveWebsites(others), policies); len(violations) > 0 {
+		return fmt.Errorf("website violates policies: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// admitPreview returns why preview cannot run. A preview of a website that
+// does not exist yet is only checked on its own, the rest is reported in its
+// status once the website exists.
+func (c *Controller) admitPreview(preview *myv1alpha1.WebsitePreview) error {
+	if err := validatePreview(preview); err != nil {
+		return err
+	}
+	parent, err := c.websitesLister.Websites(preview.Namespace).Get(preview.Spec.Website)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if effective, _, err := c.resolveWebsite(parent); err == nil {
+		parent = effective
+	}
+	if websiteGitSource(parent) == nil {
+		return fmt.Errorf(MessagePreviewWebsiteNotGit, preview.Spec.Website)
+	}
+	problems, err := c.checkPreview(preview, parent, previewWebsite(preview, parent))
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// cachesSynced returns whether the caches the admission webhook reads are
+// synced.
+func (c *Controller) cachesSynced() bool {
+	return c.websitesSynced() && c.policiesSynced() && c.clusterPoliciesSynced() && c.namespacesSynced() && c.templatesSynced() && c.clusterWebsitesSynced() && c.previewsSynced()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// adoptsExisting returns whether owner takes over obj, which it does not
+// control: only a Website with spec.adoptExisting does, and only objects
+// without a controller.
+func adoptsExisting(owner ownerObject, obj metav1.Object) bool {
+	website, ok := owner.(*myv1alpha1.Website)
+	return ok && website.Spec.AdoptExisting && metav1.GetControllerOf(obj) == nil
+}
+
+// adoptDeployment takes over an existing Deployment, replacing it with the
+// desired one, which carries the controller reference. The selector of a
+// Deployment cannot change, so it must be the desired one already.
+func (c *Controller) adoptDeployment(owner ownerObject, deployment, desired *appsv1.Deployment) (*appsv1.Deployment, error) {
+	if !equality.Semantic.DeepEqual(deployment.Spec.Selector, desired.Spec.Selector) {
+		msg := fmt.Sprintf(MessageSelectorMismatch, deployment.Name,
+			metav1.FormatLabelSelector(deployment.Spec.Selector), metav1.FormatLabelSelector(desired.Spec.Selector))
+		c.recorder.Event(owner, corev1.EventTypeWarning, ErrAdoptResource, msg)
+		return nil, fmt.Errorf(msg)
+	}
+
+	changes := []string{"set controller reference"}
+	if deployment.Spec.Replicas != nil && desired.Spec.Replicas != nil && *deployment.Spec.Replicas != *desired.Spec.Replicas {
+		changes = append(changes, fmt.Sprintf("replicas %d -> %d", *deployment.Spec.Replicas, *desired.Spec.Replicas))
+	}
+	if images, desiredImages := containerImages(&deployment.Spec.Template.Spec), containerImages(&desired.Spec.Template.Spec); images != desiredImages {
+		changes = append(changes, fmt.Sprintf("images %s -> %s", images, desiredImages))
+	}
+	if !equality.Semantic.DeepEqual(deployment.Spec.Template, desired.Spec.Template) {
+		changes = append(changes, "pod template replaced")
+	}
+	if !equality.Semantic.DeepEqual(deployment.Labels, desired.Labels) {
+		changes = append(changes, "labels replaced")
+	}
+
+	// Conditional on the adopted version, so a concurrent change of the old
+	// owner is not overwritten
+	adopted := desired.DeepCopy()
+	adopted.ResourceVersion = deployment.ResourceVersion
+	deployment, err := c.kubeclientset.AppsV1().Deployments(desired.Namespace).Update(adopted)
+	if err != nil {
+		return nil, err
+	}
+	c.recorder.Eventf(owner, corev1.EventTypeNormal, ResourceAdopted, MessageResourceAdopted, "Deployment", deployment.Name, strings.Join(changes, ", "))
+	c.recordPodTemplateOverrides(owner, desired, nil)
+	return deployment, nil
+}
+
+// adoptService takes over an existing Service, adding the controller
+// reference and bringing its selector, type, ports and labels to the desired
+// ones. Node ports of ports kept are kept, so is the cluster IP.
+func (c *Controller) adoptService(owner ownerObject, service, desired *corev1.Service) (*corev1.Service, error) {
+	changes := []string{"set controller reference"}
+	adopted := service.DeepCopy()
+	adopted.OwnerReferences = append(adopted.OwnerReferences, desired.OwnerReferences...)
+
+	if !equality.Semantic.DeepEqual(service.Spec.Selector, desired.Spec.Selector) {
+		changes = append(changes, fmt.Sprintf("selector %s -> %s",
+			labels.FormatLabels(service.Spec.Selector), labels.FormatLabels(desired.Spec.Selector)))
+		adopted.Spec.Selector = desired.Spec.Selector
+	}
+	if service.Spec.Type != desired.Spec.Type {
+		changes = append(changes, fmt.Sprintf("type %s -> %s", service.Spec.Type, desired.Spec.Type))
+		adopted.Spec.Type = desired.Spec.Type
+	}
+	ports := make([]corev1.ServicePort, len(desired.Spec.Ports))
+	for i, port := range desired.Spec.Ports {
+		ports[i] = port
+		if desired.Spec.Type == corev1.ServiceTypeClusterIP {
+			continue
+		}
+		for _, existing := range service.Spec.Ports {
+			if existing.Port == port.Port && existing.Protocol == port.Protocol {
+				ports[i].NodePort = existing.NodePort
+			}
+		}
+	}
+	if servicePorts(service.Spec.Ports) != servicePorts(ports) {
+		changes = append(changes, fmt.Sprintf("ports %s -> %s", servicePorts(service.Spec.Ports), servicePorts(ports)))
+	}
+	adopted.Spec.Ports = ports
+	if !equality.Semantic.DeepEqual(service.Labels, desired.Labels) {
+		changes = append(changes, "labels replaced")
+		adopted.Labels = desired.Labels
+	}
+
+	service, err := c.kubeclientset.CoreV1().Services(desired.Namespace).Update(adopted)
+	if err != nil {
+		return nil, err
+	}
+	c.recorder.Eventf(owner, corev1.EventTypeNormal, ResourceAdopted, MessageResourceAdopted, "Service", service.Name, strings.Join(changes, ", "))
+	return service, nil
+}
+
+// containerImages lists the images of the containers of spec for events.
+func containerImages(spec *corev1.PodSpec) string {
+	images := make([]string, 0, len(spec.Containers))
+	for _, container := range spec.Containers {
+		images = append(images, container.Image)
+	}
+	return "[" + strings.Join(images, " ") + "]"
+}
+
+// servicePorts lists ports as port:targetPort/protocol for events.
+func servicePorts(ports []corev1.ServicePort) string {
+	list := make([]string, 0, len(ports))
+	for _, port := range ports {
+		list = append(list, fmt.Sprintf("%d:%s/%s", port.Port, port.TargetPort.String(), port.Protocol))
+	}
+	return "[" + strings.Join(list, " ") + "]"
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const adoptRevision = "0123456789abcdef0123456789abcdef01234567"
+
+// newAdoptingWebsite returns a Website adopting the existing objects of its
+// names.
+func newAdoptingWebsite(replicas int32, serviceType corev1.ServiceType) *myv1alpha1.Website {
+	return &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia", UID: types.UID("kubia-uid")},
+		Spec: myv1alpha1.WebsiteSpec{
+			DeploymentName: "kubia",
+			GitRepo:        "https://github.com/nevermosby/kubia-website-example.git",
+			Replicas:       &replicas,
+			ServiceType:    serviceType,
+			AdoptExisting:  true,
+		},
+	}
+}
+
+// otherController returns the controller reference of another owner.
+func otherController() []metav1.OwnerReference {
+	other := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "legacy", UID: types.UID("legacy-uid")}}
+	return []metav1.OwnerReference{*metav1.NewControllerRef(other, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))}
+}
+
+func TestAdoptDeployment(t *testing.T) {
+	website := newAdoptingWebsite(3, "")
+	// existing returns the Deployment found, as the website would create it
+	// but without its controller reference
+	existing := func(mutate func(*appsv1.Deployment)) *appsv1.Deployment {
+		deployment := newDeployment(website, adoptRevision)
+		deployment.OwnerReferences = nil
+		deployment.ResourceVersion = "7"
+		if mutate != nil {
+			mutate(deployment)
+		}
+		return deployment
+	}
+	one := int32(1)
+	otherImage := existing(func(d *appsv1.Deployment) { d.Spec.Template.Spec.Containers[0].Image = "nginx:1.15" })
+	images := containerImages(&otherImage.Spec.Template.Spec) + " -> " + containerImages(&newDeployment(website, adoptRevision).Spec.Template.Spec)
+
+	tests := []struct {
+		name     string
+		existing *appsv1.Deployment
+		noAdopt  bool
+		// the changes reported in the ResourceAdopted event, none when the
+		// Deployment is refused
+		wantChanges string
+		wantErr     string
+	}{
+		{
+			name:        "same pod template",
+			existing:    existing(func(d *appsv1.Deployment) { d.Spec.Replicas = &one }),
+			wantChanges: "set controller reference, replicas 1 -> 3",
+		},
+		{
+			name: "other pod template",
+			existing: existing(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].Image = "nginx:1.15"
+				d.Labels = map[string]string{"team": "web"}
+			}),
+			wantChanges: "set controller reference, images " + images + ", pod template replaced, labels replaced",
+		},
+		{
+			name: "selector mismatch",
+			existing: existing(func(d *appsv1.Deployment) {
+				d.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "kubia"}}
+			}),
+			wantErr: "cannot be adopted, its selector app=kubia differs",
+		},
+		{
+			name:     "controlled by another owner",
+			existing: existing(func(d *appsv1.Deployment) { d.OwnerReferences = otherController() }),
+			wantErr:  `Resource "kubia" already exists`,
+		},
+		{
+			name:     "adoption not asked for",
+			existing: existing(nil),
+			noAdopt:  true,
+			wantErr:  `Resource "kubia" already exists`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			website := website.DeepCopy()
+			website.Spec.AdoptExisting = !tt.noAdopt
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(tt.existing); err != nil {
+				t.Fatal(err)
+			}
+			client := fake.NewSimpleClientset(tt.existing)
+			recorder := record.NewFakeRecorder(10)
+			c := &Controller{
+				kubeclientset:     client,
+				deploymentsLister: appslisters.NewDeploymentLister(indexer),
+				recorder:          recorder,
+			}
+
+			deployment, err := c.syncDeployment(website, newDeployment(website, adoptRevision))
+			event := <-recorder.Events
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("syncDeployment() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				for _, action := range client.Actions() {
+					if action.GetVerb() == "update" {
+						t.Errorf("the refused Deployment was updated")
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("syncDeployment() error = %v", err)
+			}
+			if !metav1.IsControlledBy(deployment, website) {
+				t.Errorf("adopted Deployment owners = %v, want the website", deployment.OwnerReferences)
+			}
+			if want := `Adopted Deployment "kubia": ` + tt.wantChanges; !strings.HasSuffix(event, want) {
+				t.Errorf("event %q, want %q", event, want)
+			}
+		})
+	}
+}
+
+func TestAdoptService(t *testing.T) {
+	port := func(nodePort int32) []corev1.ServicePort {
+		return []corev1.ServicePort{{Port: httpPort, TargetPort: newService(newAdoptingWebsite(1, "")).Spec.Ports[0].TargetPort, Protocol: corev1.ProtocolTCP, NodePort: nodePort}}
+	}
+	existing := func(serviceType corev1.ServiceType, nodePort int32, owners []metav1.OwnerReference) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia-npsvc", OwnerReferences: owners},
+			Spec: corev1.ServiceSpec{
+				Type:      serviceType,
+				ClusterIP: "10.96.0.10",
+				Selector:  map[string]string{"app": "kubia"},
+				Ports:     port(nodePort),
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		serviceType  corev1.ServiceType
+		existing     *corev1.Service
+		wantNodePort int32
+		wantErr      string
+	}{
+		{name: "node port kept", existing: existing(corev1.ServiceTypeNodePort, 30080, nil), wantNodePort: 30080},
+		{name: "node port of a LoadBalancer kept", serviceType: corev1.ServiceTypeLoadBalancer, existing: existing(corev1.ServiceTypeNodePort, 30080, nil), wantNodePort: 30080},
+		{name: "node port dropped for ClusterIP", serviceType: corev1.ServiceTypeClusterIP, existing: existing(corev1.ServiceTypeNodePort, 30080, nil)},
+		{name: "controlled by another owner", existing: existing(corev1.ServiceTypeNodePort, 30080, otherController()), wantErr: `Resource "kubia-npsvc" already exists`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			website := newAdoptingWebsite(1, tt.serviceType)
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(tt.existing); err != nil {
+				t.Fatal(err)
+			}
+			c := &Controller{
+				kubeclientset:  fake.NewSimpleClientset(tt.existing),
+				servicesLister: corelisters.NewServiceLister(indexer),
+				recorder:       record.NewFakeRecorder(10),
+			}
+
+			service, err := c.syncService(website, newService(website))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("syncService() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("syncService() error = %v", err)
+			}
+			if !metav1.IsControlledBy(service, website) {
+				t.Errorf("adopted Service owners = %v, want the website", service.OwnerReferences)
+			}
+			if got := service.Spec.Ports[0].NodePort; got != tt.wantNodePort {
+				t.Errorf("node port = %d, want %d", got, tt.wantNodePort)
+			}
+			if service.Spec.ClusterIP != "10.96.0.10" {
+				t.Errorf("cluster IP = %q, want it kept", service.Spec.ClusterIP)
+			}
+			if service.Spec.Selector["app"] != "website-nginx" {
+				t.Errorf("selector = %v, want the website pods", service.Spec.Selector)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// authSecretIndex is the name of the Website informer index keyed by
+	// the namespace/name of the spec.auth Secret.
+	authSecretIndex = "authSecret"
+
+	// secretHashAnnotation on the pod template records a hash of the
+	// Secrets mounted by the pods, so a changed Secret rolls the pods.
+	secretHashAnnotation = "mycontroller.nevermosby.io/secret-hash"
+
+	// authDir is where the htpasswd file is mounted in the serving
+	// container, as authFileName.
+	authDir      = "/etc/website/auth"
+	authFileName = "htpasswd"
+
+	// defaultAuthKey is the Secret key of the htpasswd file when spec.auth
+	// does not name one, as used by ingress-nginx.
+	defaultAuthKey = "auth"
+
+	// defaultAuthRealm is shown by browsers when spec.auth has no realm.
+	defaultAuthRealm = "Restricted"
+)
+
+// indexWebsiteByAuthSecret is the cache.IndexFunc for authSecretIndex.
+func indexWebsiteByAuthSecret(obj interface{}) ([]string, error) {
+	website, ok := websiteOf(obj)
+	if !ok || website.Spec.Auth == nil || website.Spec.Auth.SecretName == "" {
+		return nil, nil
+	}
+	return []string{website.Namespace + "/" + website.Spec.Auth.SecretName}, nil
+}
+
+// validateAuth checks the parts of spec.auth rendered into the server
+// configuration.
+func validateAuth(auth *myv1alpha1.WebsiteAuth) error {
+	if auth == nil {
+		return nil
+	}
+	if auth.SecretName == "" {
+		return fmt.Errorf("auth.secretName is required")
+	}
+	if hasControlChars(auth.Realm) {
+		return fmt.Errorf("auth.realm must not contain control characters")
+	}
+	for i, p := range auth.Paths {
+		if !locationPattern.MatchString(p) {
+			return fmt.Errorf("auth.paths[%d]: path %q must be an absolute path of letters, digits, '.', '_', '-' and '/'", i, p)
+		}
+	}
+	return nil
+}
+
+// authCovers tells whether requests to location must authenticate. Servers
+// pick the longest matching prefix, so a location below a protected path
+// needs the auth directives as well.
+func authCovers(auth *myv1alpha1.WebsiteAuth, location string) bool {
+	for _, p := range auth.Paths {
+		p = strings.TrimRight(p, "/")
+		if p == "" || location == p || strings.HasPrefix(location, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAuth mounts the htpasswd file of spec.auth in the serving container.
+func applyAuth(template *corev1.PodTemplateSpec, website *myv1alpha1.Website) {
+	key := website.Spec.Auth.Key
+	if key == "" {
+		key = defaultAuthKey
+	}
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: "basic-auth",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: website.Spec.Auth.SecretName,
+				Items:      []corev1.KeyToPath{{Key: key, Path: authFileName}},
+			},
+		},
+	})
+	if container := servingContainer(template, website); container != nil {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      "basic-auth",
+			MountPath: authDir,
+			ReadOnly:  true,
+		})
+	}
+}
+
+// stampSecretHash sets secretHashAnnotation on a pod template to a hash of
+// the data of the Secrets it mounts. Kubelet updates mounted Secrets on its
+// own, but servers read the htpasswd file when they start.
+func (c *Controller) stampSecretHash(owner ownerObject, template *corev1.PodTemplateSpec) error {
+	var names []string
+	for _, volume := range template.Spec.Volumes {
+		if volume.Secret != nil {
+			names = append(names, volume.Secret.SecretName)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		secret, err := c.secretsLister.Secrets(owner.GetNamespace()).Get(name)
+		if errors.IsNotFound(err) {
+			msg := fmt.Sprintf(MessageAuthSecretNotFound, name)
+			c.recorder.Event(owner, corev1.EventTypeWarning, ErrAuthSecret, msg)
+			return fmt.Errorf(msg)
+		}
+		if err != nil {
+			return err
+		}
+		hashSecretData(h, secret)
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[secretHashAnnotation] = hex.EncodeToString(h.Sum(nil))[:10]
+	return nil
+}
+
+func hashSecretData(w io.Writer, secret *corev1.Secret) {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(w, "%s\x00", secret.Name)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\x00%s\x00", key, secret.Data[key])
+	}
+}
+
+// handleSecret enqueues the websites protected with a Secret, and their
+// previews, when it changes.
+func (c *Controller) handleSecret(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	websites, err := c.websitesIndexer.ByIndex(authSecretIndex, key)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, website := range websites {
+		c.enqueueWebsite(website)
+		c.enqueueWebsitePreviews(website)
+	}
+	clusterWebsites, err := c.clusterWebsitesIndexer.ByIndex(authSecretIndex, key)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, clusterWebsite := range clusterWebsites {
+		c.enqueueWebsite(clusterWebsite)
+	}
+}
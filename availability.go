@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// The Kubernetes defaults of the Deployment settings, set explicitly so the
+// Deployments can be compared with the desired ones as they are.
+const (
+	defaultMaxSurge                = "25%"
+	defaultMaxUnavailable          = "25%"
+	defaultProgressDeadlineSeconds = 600
+	defaultRevisionHistoryLimit    = 10
+)
+
+// validateAvailability returns an error when spec.availability does not
+// set exactly one bound, or spec.deployment an unknown strategy.
+func validateAvailability(website *myv1alpha1.Website) error {
+	if availability := website.Spec.Availability; availability != nil {
+		if (availability.MinAvailable == nil) == (availability.MaxUnavailable == nil) {
+			return fmt.Errorf("spec.availability: exactly one of minAvailable and maxUnavailable must be set")
+		}
+	}
+	deployment := website.Spec.Deployment
+	if deployment == nil || deployment.Strategy == nil {
+		return nil
+	}
+	switch deployment.Strategy.Type {
+	case "", appsv1.RollingUpdateDeploymentStrategyType:
+	case appsv1.RecreateDeploymentStrategyType:
+		if deployment.Strategy.RollingUpdate != nil {
+			return fmt.Errorf("spec.deployment.strategy.rollingUpdate: may not be set with the %s strategy", appsv1.RecreateDeploymentStrategyType)
+		}
+	default:
+		return fmt.Errorf("spec.deployment.strategy.type: unknown strategy %q, expected %s or %s", deployment.Strategy.Type, appsv1.RollingUpdateDeploymentStrategyType, appsv1.RecreateDeploymentStrategyType)
+	}
+	return nil
+}
+
+// applyDeploymentSettings sets the strategy, minReadySeconds,
+// progressDeadlineSeconds and revisionHistoryLimit of spec.deployment on
+// the Deployment, and the Kubernetes defaults for those unset.
+func applyDeploymentSettings(deployment *appsv1.Deployment, website *myv1alpha1.Website) {
+	settings := website.Spec.Deployment
+	if settings == nil {
+		settings = &myv1alpha1.WebsiteDeployment{}
+	}
+	strategy := appsv1.DeploymentStrategy{}
+	if settings.Strategy != nil {
+		strategy = *settings.Strategy.DeepCopy()
+	}
+	if strategy.Type == "" {
+		strategy.Type = appsv1.RollingUpdateDeploymentStrategyType
+	}
+	if strategy.Type == appsv1.RollingUpdateDeploymentStrategyType {
+		if strategy.RollingUpdate == nil {
+			strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{}
+		}
+		if strategy.RollingUpdate.MaxSurge == nil {
+			maxSurge := intstr.FromString(defaultMaxSurge)
+			strategy.RollingUpdate.MaxSurge = &maxSurge
+		}
+		if strategy.RollingUpdate.MaxUnavailable == nil {
+			maxUnavailable := intstr.FromString(defaultMaxUnavailable)
+			strategy.RollingUpdate.MaxUnavailable = &maxUnavailable
+		}
+	}
+	progressDeadline := int32(defaultProgressDeadlineSeconds)
+	if settings.ProgressDeadlineSeconds != nil {
+		progressDeadline = *settings.ProgressDeadlineSeconds
+	}
+	revisionHistory := int32(defaultRevisionHistoryLimit)
+	if settings.RevisionHistoryLimit != nil {
+		revisionHistory = *settings.RevisionHistoryLimit
+	}
+
+	deployment.Spec.Strategy = strategy
+	deployment.Spec.MinReadySeconds = settings.MinReadySeconds
+	deployment.Spec.ProgressDeadlineSeconds = &progressDeadline
+	deployment.Spec.RevisionHistoryLimit = &revisionHistory
+}
+
+// deploymentSettingsDrifted returns whether the rollout settings of the
+// current Deployment differ from the desired ones.
+func deploymentSettingsDrifted(desired, current *appsv1.Deployment) bool {
+	return !equality.Semantic.DeepEqual(desired.Spec.Strategy, current.Spec.Strategy) ||
+		desired.Spec.MinReadySeconds != current.Spec.MinReadySeconds ||
+		!equality.Semantic.DeepEqual(desired.Spec.ProgressDeadlineSeconds, current.Spec.ProgressDeadlineSeconds) ||
+		!equality.Semantic.DeepEqual(desired.Spec.RevisionHistoryLimit, current.Spec.RevisionHistoryLimit)
+}
+
+// syncPodDisruptionBudget bounds the disruptions of the website pods, and
+// removes the PodDisruptionBudget it owns once spec.availability is unset.
+func (c *Controller) syncPodDisruptionBudget(website *myv1alpha1.Website) error {
+	if website.Spec.Availability == nil {
+		return c.deletePodDisruptionBudget(website)
+	}
+	desired := newPodDisruptionBudget(website)
+	pdb, err := c.pdbsLister.PodDisruptionBudgets(desired.Namespace).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		_, err = c.kubeclientset.PolicyV1beta1().PodDisruptionBudgets(desired.Namespace).Create(desired)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(pdb, website) {
+		msg := fmt.Sprintf(MessageResourceExists, pdb.Name)
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+	if equality.Semantic.DeepEqual(pdb.Spec, desired.Spec) {
+		return nil
+	}
+	klog.V(4).Infof("PodDisruptionBudget %s/%s drifted, updating", desired.Namespace, desired.Name)
+	pdbCopy := pdb.DeepCopy()
+	pdbCopy.Spec = desired.Spec
+	_, err = c.kubeclientset.PolicyV1beta1().PodDisruptionBudgets(desired.Namespace).Update(pdbCopy)
+	return err
+}
+
+// deletePodDisruptionBudget deletes the PodDisruptionBudget of the website
+// if it controls it.
+func (c *Controller) deletePodDisruptionBudget(website *myv1alpha1.Website) error {
+	name := website.Spec.DeploymentName
+	pdb, err := c.pdbsLister.PodDisruptionBudgets(website.Namespace).Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(pdb, website) {
+		return nil
+	}
+	err = c.kubeclientset.PolicyV1beta1().PodDisruptionBudgets(website.Namespace).Delete(name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// websitePodSelector selects the pods of all Deployments of the website:
+// the plain, canary and blue/green ones, which differ in app.
+func websitePodSelector(website *myv1alpha1.Website) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{"controller": website.Name},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      "app",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"website-nginx", "website-nginx-canary", "website-nginx-bluegreen"},
+			},
+		},
+	}
+}
+
+// newPodDisruptionBudget creates a new PodDisruptionBudget covering the
+// pods of all Deployments of the website, e.g. both colors of a blue/green
+// one, but not its previews.
+func newPodDisruptionBudget(website *myv1alpha1.Website) *policyv1beta1.PodDisruptionBudget {
+	availability := website.Spec.Availability
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      website.Spec.DeploymentName,
+			Namespace: website.Namespace,
+			Labels: map[string]string{
+				"app":        "website",
+				"controller": website.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*websiteControllerRef(website),
+			},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: websitePodSelector(website),
+		},
+	}
+	if availability.MinAvailable != nil {
+		minAvailable := *availability.MinAvailable
+		pdb.Spec.MinAvailable = &minAvailable
+	}
+	if availability.MaxUnavailable != nil {
+		maxUnavailable := *availability.MaxUnavailable
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	}
+	return pdb
+}
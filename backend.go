@@ -0,0 +1,167 @@
+package main
+
+import (
+	"path"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// serverBackend is a web server able to serve a website. It provides the
+// serving container and renders spec.server and spec.auth into its own
+// configuration format.
+type serverBackend interface {
+	// Name is the spec.server.type of the backend and the name of its
+	// container.
+	Name() string
+	// Container returns the serving container with the site mounted at
+	// HTMLDir.
+	Container(website *myv1alpha1.Website) corev1.Container
+	// HTMLDir is the directory the site is served from.
+	HTMLDir() string
+	// ConfigKey is the ConfigMap key of the rendered configuration.
+	ConfigKey() string
+	// ConfigMount returns where the rendered configuration is mounted,
+	// without volume name.
+	ConfigMount() corev1.VolumeMount
+	// RenderConfig renders the configuration of the website.
+	RenderConfig(website *myv1alpha1.Website) string
+	// WritableDirs are the directories besides /tmp the server writes to,
+	// which get a tmpfs when the root filesystem is read-only.
+	WritableDirs() []string
+}
+
+// serverBackends are the backends selectable with spec.server.type.
+var serverBackends = map[myv1alpha1.ServerType]serverBackend{
+	myv1alpha1.ServerTypeNginx: nginxBackend{},
+	myv1alpha1.ServerTypeCaddy: caddyBackend{},
+	myv1alpha1.ServerTypeHTTPD: httpdBackend{},
+}
+
+// websiteServerType returns spec.server.type, nginx when unset.
+func websiteServerType(website *myv1alpha1.Website) myv1alpha1.ServerType {
+	if website.Spec.Server == nil || website.Spec.Server.Type == "" {
+		return myv1alpha1.ServerTypeNginx
+	}
+	return website.Spec.Server.Type
+}
+
+// backendFor returns the backend serving the website.
+func backendFor(website *myv1alpha1.Website) serverBackend {
+	backend, ok := serverBackends[websiteServerType(website)]
+	if !ok {
+		// syncHandler refuses such a website before building pods for it
+		return nginxBackend{}
+	}
+	return backend
+}
+
+// servingContainer returns the container of the backend in a pod template,
+// or nil if there is none.
+func servingContainer(template *corev1.PodTemplateSpec, website *myv1alpha1.Website) *corev1.Container {
+	name := backendFor(website).Name()
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == name {
+			return &template.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
+// htmlMount mounts the served volume at the HTML directory of a backend.
+func htmlMount(backend serverBackend) corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      "html",
+		MountPath: backend.HTMLDir(),
+		ReadOnly:  true,
+	}
+}
+
+// serverRoot returns the directory the backend serves the website from: a
+// built site is published to the top of the HTML directory, a checkout is
+// served from spec.contentPath.
+func serverRoot(backend serverBackend, website *myv1alpha1.Website) string {
+	if website.Spec.Build != nil {
+		return backend.HTMLDir()
+	}
+	return path.Join(backend.HTMLDir(), websiteContentPath(website))
+}
+
+// serverLocations returns the sorted path prefixes needing their own
+// configuration section, with the response headers of each. Headers of /
+// apply to the whole site, more specific paths add to or override them.
+// Paths protected by spec.auth get a section as well.
+func serverLocations(website *myv1alpha1.Website) ([]string, map[string]map[string]string) {
+	headers := map[string]map[string]string{"/": {}}
+	inherit := func(p string) {
+		if headers[p] == nil {
+			headers[p] = map[string]string{}
+			for name, value := range headers["/"] {
+				headers[p][name] = value
+			}
+		}
+	}
+	if server := website.Spec.Server; server != nil {
+		for _, h := range server.Headers {
+			if h.Path == "" || h.Path == "/" {
+				for name, value := range h.Headers {
+					headers["/"][name] = value
+				}
+			}
+		}
+		for _, h := range server.Headers {
+			if h.Path == "" || h.Path == "/" {
+				continue
+			}
+			inherit(h.Path)
+			for name, value := range h.Headers {
+				headers[h.Path][name] = value
+			}
+		}
+	}
+	if auth := website.Spec.Auth; auth != nil {
+		for _, p := range auth.Paths {
+			inherit(p)
+		}
+	}
+	locations := make([]string, 0, len(headers))
+	for location := range headers {
+		locations = append(locations, location)
+	}
+	// A prefix sorts before the paths below it, so backends applying
+	// sections in order let the more specific ones win
+	sort.Strings(locations)
+	return locations, headers
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// authRealm returns spec.auth.realm, defaultAuthRealm when unset.
+func authRealm(auth *myv1alpha1.WebsiteAuth) string {
+	if auth.Realm == "" {
+		return defaultAuthRealm
+	}
+	return auth.Realm
+}
+
+// locationAuth tells whether the section of location must authenticate,
+// when the auth is scoped to paths.
+func locationAuth(website *myv1alpha1.Website, location string) bool {
+	auth := website.Spec.Auth
+	return auth != nil && len(auth.Paths) > 0 && authCovers(auth, location)
+}
+
+// siteAuth tells whether the whole site must authenticate.
+func siteAuth(website *myv1alpha1.Website) bool {
+	return website.Spec.Auth != nil && len(website.Spec.Auth.Paths) == 0
+}
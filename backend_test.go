@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+var update = flag.Bool("update", false, "update the .golden files in testdata")
+
+// TestRenderConfig compares the configuration every backend renders with
+// testdata/<case>.<backend>.golden. Run with -update after a deliberate
+// change of the output.
+func TestRenderConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		spec myv1alpha1.WebsiteSpec
+	}{
+		{name: "defaults"},
+		{
+			name: "server",
+			spec: myv1alpha1.WebsiteSpec{
+				Server: &myv1alpha1.WebsiteServer{
+					Redirects: []myv1alpha1.WebsiteRedirect{
+						{From: "^/old/(.*)$", To: "/new/$1"},
+						{From: "^/blog$", To: "https://blog.example.com/", Code: 302},
+						{From: "^/docs/latest/(.*)$", To: "/docs/v2/$1", Rewrite: true},
+					},
+					Headers: []myv1alpha1.WebsiteHeaders{
+						{Headers: map[string]string{"Content-Security-Policy": "default-src 'self'", "X-Frame-Options": "DENY"}},
+						{Path: "/assets", Headers: map[string]string{"Cache-Control": "public, max-age=31536000"}},
+					},
+					NotFoundPage:     "/404.html",
+					Gzip:             true,
+					DirectoryListing: true,
+				},
+			},
+		},
+		{
+			name: "spa",
+			spec: myv1alpha1.WebsiteSpec{
+				ContentPath: "public",
+				Server:      &myv1alpha1.WebsiteServer{SPAFallback: true},
+			},
+		},
+		{
+			name: "site-auth",
+			spec: myv1alpha1.WebsiteSpec{
+				Auth: &myv1alpha1.WebsiteAuth{SecretName: "kubia-htpasswd", Realm: `Kubia "staff"`},
+			},
+		},
+		{
+			name: "path-auth",
+			spec: myv1alpha1.WebsiteSpec{
+				Server: &myv1alpha1.WebsiteServer{
+					Headers: []myv1alpha1.WebsiteHeaders{
+						{Path: "/admin", Headers: map[string]string{"Cache-Control": "no-store"}},
+					},
+				},
+				Auth: &myv1alpha1.WebsiteAuth{SecretName: "kubia-htpasswd", Paths: []string{"/admin", "/drafts"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		for serverType, backend := range serverBackends {
+			t.Run(tt.name+"/"+string(serverType), func(t *testing.T) {
+				website := &myv1alpha1.Website{Spec: *tt.spec.DeepCopy()}
+				website.Spec.DeploymentName = "kubia"
+				website.Spec.GitRepo = "https://github.com/nevermosby/kubia-website-example.git"
+				if website.Spec.Server == nil {
+					website.Spec.Server = &myv1alpha1.WebsiteServer{}
+				}
+				website.Spec.Server.Type = serverType
+				if err := validateWebsite(website); err != nil {
+					t.Fatalf("invalid test website: %v", err)
+				}
+
+				got := backend.RenderConfig(website)
+				golden := filepath.Join("testdata", tt.name+"."+string(serverType)+".golden")
+				if *update {
+					if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != string(want) {
+					t.Errorf("RenderConfig() differs from %s:\n%s", golden, got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// colorLabel tells the blue and green pods of a Website apart.
+	colorLabel = "color"
+
+	blue  = "blue"
+	green = "green"
+
+	// defaultScaleDownDelay is how long the previous color keeps running
+	// after a switch when spec.strategy.blueGreen.scaleDownDelay is unset.
+	defaultScaleDownDelay = 10 * time.Minute
+)
+
+// blueGreenStrategy returns the blue/green settings of the website, or nil
+// when new revisions are rolled out another way.
+func blueGreenStrategy(website *myv1alpha1.Website) *myv1alpha1.BlueGreenStrategy {
+	strategy := website.Spec.Strategy
+	if strategy == nil || strategy.Type != myv1alpha1.BlueGreenWebsiteStrategy {
+		return nil
+	}
+	if strategy.BlueGreen == nil {
+		return &myv1alpha1.BlueGreenStrategy{}
+	}
+	return strategy.BlueGreen
+}
+
+func scaleDownDelay(strategy *myv1alpha1.BlueGreenStrategy) time.Duration {
+	if strategy.ScaleDownDelay != nil {
+		return strategy.ScaleDownDelay.Duration
+	}
+	return defaultScaleDownDelay
+}
+
+func otherColor(color string) string {
+	if color == blue {
+		return green
+	}
+	return blue
+}
+
+// colorName returns the name of the Deployment of a color.
+func colorName(website *myv1alpha1.Website, color string) string {
+	return website.Spec.DeploymentName + "-" + color
+}
+
+// colorLabels returns the labels of the pods of a color. They differ from
+// the labels of the plain Deployment in app, so that switching a Website to
+// blue/green never mixes its old and new pods either.
+func colorLabels(website *myv1alpha1.Website, color string) map[string]string {
+	return map[string]string{
+		"app":        "website-nginx-bluegreen",
+		"controller": website.Name,
+		colorLabel:   color,
+	}
+}
+
+// newColorDeployment creates the Deployment of a color running revision.
+func newColorDeployment(website *myv1alpha1.Website, color, revision string, replicas int32) *appsv1.Deployment {
+	deployment := newDeployment(website, revision)
+	labels := colorLabels(website, color)
+	deployment.Name = colorName(website, color)
+	deployment.Spec.Replicas = &replicas
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	deployment.Spec.Template.Labels = labels
+	return deployment
+}
+
+// syncBlueGreen brings the idle color up on revision and, once all of its
+// replicas are available, switches the website service over to it in one
+// go. The previous color keeps running for the scale down delay. It returns
+// the active Deployment, the revision it serves and the service selector.
+func (c *Controller) syncBlueGreen(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, revision string) (*appsv1.Deployment, string, map[string]string, error) {
+	strategy := blueGreenStrategy(website)
+	if err := c.deleteCanary(website); err != nil {
+		return nil, "", nil, err
+	}
+	if status.BlueGreen == nil {
+		status.BlueGreen = &myv1alpha1.BlueGreenStatus{}
+	}
+	bg := status.BlueGreen
+	replicas := websiteReplicas(website)
+
+	active := bg.ActiveColor
+	idle := blue
+	var activeDeployment *appsv1.Deployment
+	var selector map[string]string
+	var err error
+	if active != "" {
+		idle = otherColor(active)
+		selector = colorLabels(website, active)
+		// the active color only follows the replicas of the spec
+		activeDeployment, err = c.syncDeployment(website, newColorDeployment(website, active, status.Revision, replicas))
+	} else {
+		// until the first switch the plain Deployment, if any, keeps serving
+		activeDeployment, err = c.deploymentsLister.Deployments(website.Namespace).Get(website.Spec.DeploymentName)
+		if errors.IsNotFound(err) {
+			activeDeployment, err = nil, nil
+		}
+	}
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if active == "" || revision != status.Revision {
+		idleDeployment, err := c.syncDeployment(website, newColorDeployment(website, idle, revision, replicas))
+		if err != nil {
+			return nil, "", nil, err
+		}
+		bg.IdleRevision = revision
+		if !deploymentComplete(idleDeployment) {
+			bg.Message = fmt.Sprintf("waiting for %s to become available with revision %s", idle, revision)
+			return activeDeployment, status.Revision, selector, nil
+		}
+
+		now := metav1.Now()
+		c.recorder.Eventf(website, corev1.EventTypeNormal, BlueGreenSwitched, MessageBlueGreenSwitched, idle, revision)
+		bg.ActiveColor = idle
+		bg.IdleRevision = ""
+		if active != "" {
+			bg.IdleRevision = status.Revision
+		}
+		bg.SwitchTime = &now
+		bg.Message = ""
+		c.enqueueWebsiteAfter(website, scaleDownDelay(strategy))
+		return idleDeployment, revision, colorLabels(website, idle), nil
+	}
+
+	// Scale the previous color down once the delay since the switch passed.
+	// Until then a rollback to its revision switches back instantly.
+	if bg.SwitchTime != nil {
+		if remaining := scaleDownDelay(strategy) - time.Since(bg.SwitchTime.Time); remaining > 0 {
+			c.enqueueWebsiteAfter(website, remaining)
+			return activeDeployment, status.Revision, selector, nil
+		}
+	}
+	if err := c.scaleDownColor(website, idle); err != nil {
+		return nil, "", nil, err
+	}
+	bg.IdleRevision = ""
+	if err := c.deleteDeployment(website, website.Spec.DeploymentName); err != nil {
+		return nil, "", nil, err
+	}
+	return activeDeployment, status.Revision, selector, nil
+}
+
+// scaleDownColor scales the Deployment of a color to zero, keeping its
+// revision so a later switch only has to scale it up again.
+func (c *Controller) scaleDownColor(website *myv1alpha1.Website, color string) error {
+	deployment, err := c.deploymentsLister.Deployments(website.Namespace).Get(colorName(website, color))
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(deployment, website) || (deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0) {
+		return nil
+	}
+	_, err = c.syncDeployment(website, newColorDeployment(website, color, deployment.Spec.Template.Annotations[revisionAnnotation], 0))
+	return err
+}
+
+// finishBlueGreen cleans up after a website switched from blue/green to
+// another strategy. The active color keeps serving until deployment, the
+// Deployment replacing it, is fully available. It returns the service
+// selector to use meanwhile.
+func (c *Controller) finishBlueGreen(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, deployment *appsv1.Deployment) (map[string]string, error) {
+	bg := status.BlueGreen
+	if bg == nil {
+		return nil, nil
+	}
+	if bg.ActiveColor != "" && !deploymentComplete(deployment) {
+		return colorLabels(website, bg.ActiveColor), nil
+	}
+	for _, color := range []string{blue, green} {
+		if err := c.deleteDeployment(website, colorName(website, color)); err != nil {
+			return nil, err
+		}
+	}
+	status.BlueGreen = nil
+	return nil, nil
+}
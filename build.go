@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// buildAnnotation on the pod template records a hash of spec.build, so
+	// a changed build is rolled out like a new revision.
+	buildAnnotation = "mycontroller.nevermosby.io/build"
+
+	// buildContainerName is the init container running the build command.
+	buildContainerName = "build"
+
+	// buildSourceDir is where the checkout is mounted in the build pods.
+	buildSourceDir = "/src"
+
+	// buildLogLines and buildLogBytes bound the log excerpt of a failed
+	// build kept in status.
+	buildLogLines = 20
+	buildLogBytes = 4096
+)
+
+// publishScript copies the build output into the directory the server
+// backend serves.
+const publishScript = `cp -a "` + buildSourceDir + `/$OUTPUT_DIR/." "$HTML_DIR/"`
+
+// buildOutputDir returns spec.build.outputDir cleaned, or an error when it
+// points outside of the checkout.
+func buildOutputDir(build *myv1alpha1.WebsiteBuild) (string, error) {
+	return cleanRelativePath("build output directory", build.OutputDir)
+}
+
+// applyBuild fills the served volume of a website pod with init containers
+// that fetch the source, build it and publish the output. A failing build keeps the new pods from becoming
+// ready, so the Deployment keeps serving the previous revision.
+func applyBuild(template *corev1.PodTemplateSpec, website *myv1alpha1.Website, revision string) {
+	build := website.Spec.Build
+	outputDir, err := buildOutputDir(build)
+	if err != nil {
+		// syncHandler refuses such a website before building pods for it
+		outputDir = "."
+	}
+	// The build runs in the content path and writes relative to it
+	contentPath := websiteContentPath(website)
+	backend := backendFor(website)
+	template.Annotations[buildAnnotation] = hashJSON(build)
+
+	spec := &template.Spec
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: "source",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+	source := corev1.VolumeMount{Name: "source", MountPath: buildSourceDir}
+	readOnlySource := source
+	readOnlySource.ReadOnly = true
+	addFetchContainer(template, websiteSource(website), "", revision, source)
+	spec.InitContainers = append(spec.InitContainers,
+		corev1.Container{
+			Name:         buildContainerName,
+			Image:        build.Image,
+			Command:      build.Command,
+			Env:          build.Env,
+			WorkingDir:   path.Join(buildSourceDir, contentPath),
+			VolumeMounts: []corev1.VolumeMount{source},
+		},
+		corev1.Container{
+			// The backend image is pulled anyway and has a shell
+			Name:    "publish",
+			Image:   backend.Container(website).Image,
+			Command: []string{"sh", "-c", publishScript},
+			Env: []corev1.EnvVar{
+				{Name: "OUTPUT_DIR", Value: path.Join(contentPath, outputDir)},
+				{Name: "HTML_DIR", Value: backend.HTMLDir()},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				readOnlySource,
+				{Name: "html", MountPath: backend.HTMLDir()},
+			},
+		},
+	)
+}
+
+// syncBuild reports in status.build how the build of revision goes in the
+// website pods, with the tail of the build log when it failed.
+func (c *Controller) syncBuild(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, revision string) error {
+	if website.Spec.Build == nil {
+		status.Build = nil
+		return nil
+	}
+	pods, err := c.websitePods(website)
+	if err != nil {
+		return err
+	}
+
+	build := &myv1alpha1.BuildStatus{Revision: revision, Phase: myv1alpha1.BuildRunning}
+	var failedPod *corev1.Pod
+	var failed *corev1.ContainerStateTerminated
+	previous := false
+	for _, pod := range pods {
+		if pod.Annotations[revisionAnnotation] != revision || pod.Labels["app"] == "website-nginx-preview" {
+			continue
+		}
+		for i := range pod.Status.InitContainerStatuses {
+			st := &pod.Status.InitContainerStatuses[i]
+			if st.Name != buildContainerName {
+				continue
+			}
+			switch {
+			case st.State.Terminated != nil && st.State.Terminated.ExitCode == 0:
+				build.Phase = myv1alpha1.BuildSucceeded
+			case st.State.Terminated != nil:
+				failedPod, failed, previous = pod, st.State.Terminated, false
+			case st.LastTerminationState.Terminated != nil && st.LastTerminationState.Terminated.ExitCode != 0:
+				failedPod, failed, previous = pod, st.LastTerminationState.Terminated, true
+			}
+		}
+	}
+
+	if build.Phase != myv1alpha1.BuildSucceeded && failed != nil {
+		build.Phase = myv1alpha1.BuildFailed
+		build.Message = fmt.Sprintf("build exited with code %d", failed.ExitCode)
+		if failed.Reason != "" {
+			build.Message += " (" + failed.Reason + ")"
+		}
+		if old := status.Build; old != nil && old.Revision == revision && old.Phase == myv1alpha1.BuildFailed && old.Log != "" {
+			build.Log = old.Log
+		} else {
+			build.Log = c.buildLog(failedPod, previous)
+		}
+	}
+
+	if old := status.Build; old == nil || old.Revision != build.Revision || old.Phase != build.Phase {
+		switch build.Phase {
+		case myv1alpha1.BuildFailed:
+			c.recorder.Eventf(website, corev1.EventTypeWarning, BuildFailed, MessageBuildFailed, revision, build.Message)
+		case myv1alpha1.BuildSucceeded:
+			c.recorder.Eventf(website, corev1.EventTypeNormal, BuildSucceeded, MessageBuildSucceeded, revision)
+		}
+	}
+	status.Build = build
+	return nil
+}
+
+// buildLog returns the tail of the build container log of pod, or of its
+// previous run when the container was restarted since it failed.
+func (c *Controller) buildLog(pod *corev1.Pod, previous bool) string {
+	lines := int64(buildLogLines)
+	limit := int64(buildLogBytes)
+	data, err := c.kubeclientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  buildContainerName,
+		Previous:   previous,
+		TailLines:  &lines,
+		LimitBytes: &limit,
+	}).Do().Raw()
+	if err != nil {
+		klog.V(4).Infof("Unable to fetch build log of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return ""
+	}
+	return string(data)
+}
+
+// handlePod enqueues the website of a pod running a build or serving
+// spec.sources. Website pods are owned by ReplicaSets, so they are matched
+// by the owner of their Deployment.
+func (c *Controller) handlePod(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	if pod.Labels["controller"] == "" || pod.Labels["app"] == "website-nginx-preview" {
+		return
+	}
+	key := c.podWebsiteKey(pod)
+	if key == "" {
+		return
+	}
+	// Pods of spec.sources report how their sources sync, evictions and OOM
+	// kills show up in the website conditions
+	_, _, oomKilled := podOOMKilled(pod)
+	if _, ok := pod.Annotations[sourcesAnnotation]; ok || podEvicted(pod) || oomKilled {
+		c.workqueue.Add(key)
+		return
+	}
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == buildContainerName {
+			c.workqueue.Add(key)
+			return
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// caddyUsersFile holds the htpasswd file converted to the user and
+	// hash lines the basicauth directive expects.
+	caddyUsersFile = "/tmp/caddy-users"
+
+	// caddyAuthScript converts the mounted htpasswd file before starting
+	// caddy like the stock entrypoint does.
+	caddyAuthScript = `sed 's/:/ /' "$AUTH_FILE" > ` + caddyUsersFile + ` && exec caddy run --config /etc/caddy/Caddyfile --adapter caddyfile`
+)
+
+// regexpGroupPattern matches the $1 style group references of redirects.
+var regexpGroupPattern = regexp.MustCompile(`\$([0-9])`)
+
+// caddyBackend serves websites with the official caddy image. Its
+// rendered configuration replaces the stock Caddyfile. Caddy only accepts
+// bcrypt hashes, so htpasswd files must be created with htpasswd -B.
+type caddyBackend struct{}
+
+func (caddyBackend) Name() string { return string(myv1alpha1.ServerTypeCaddy) }
+
+func (caddyBackend) HTMLDir() string { return "/usr/share/caddy" }
+
+func (caddyBackend) ConfigKey() string { return "Caddyfile" }
+
+func (caddyBackend) ConfigMount() corev1.VolumeMount {
+	return corev1.VolumeMount{MountPath: "/etc/caddy"}
+}
+
+// WritableDirs are XDG_DATA_HOME and XDG_CONFIG_HOME of the caddy image,
+// where caddy keeps its state and autosaved configuration.
+func (caddyBackend) WritableDirs() []string { return []string{"/data", "/config"} }
+
+func (b caddyBackend) Container(website *myv1alpha1.Website) corev1.Container {
+	container := corev1.Container{
+		Name:         b.Name(),
+		Image:        "caddy:2",
+		VolumeMounts: []corev1.VolumeMount{htmlMount(b)},
+		Ports:        []corev1.ContainerPort{serverContainerPort(website)},
+	}
+	if website.Spec.Auth != nil {
+		container.Command = []string{"sh", "-c", caddyAuthScript}
+		container.Env = []corev1.EnvVar{{Name: "AUTH_FILE", Value: path.Join(authDir, authFileName)}}
+	}
+	return container
+}
+
+// RenderConfig renders the Caddyfile. Caddy orders directives on its own,
+// so redirects, headers and the SPA fallback go into a route to be applied
+// in the order nginx applies them.
+func (b caddyBackend) RenderConfig(website *myv1alpha1.Website) string {
+	server := website.Spec.Server
+	if server == nil {
+		server = &myv1alpha1.WebsiteServer{}
+	}
+	root := serverRoot(b, website)
+
+	var w strings.Builder
+	fmt.Fprintf(&w, ":%d {\n", serverPort(website))
+	fmt.Fprintf(&w, "\troot * %s\n", root)
+	if server.Gzip {
+		w.WriteString("\tencode gzip\n")
+	}
+	if auth := website.Spec.Auth; auth != nil {
+		matcher := ""
+		if len(auth.Paths) > 0 {
+			w.WriteString("\t@auth path")
+			for _, p := range auth.Paths {
+				fmt.Fprintf(&w, " %s", caddyPathMatcher(strings.TrimRight(p, "/")))
+			}
+			w.WriteString("\n")
+			matcher = " @auth"
+		}
+		fmt.Fprintf(&w, "\tbasicauth%s bcrypt %s {\n", matcher, quoteString(authRealm(auth)))
+		fmt.Fprintf(&w, "\t\timport %s\n", caddyUsersFile)
+		w.WriteString("\t}\n")
+	}
+
+	for i, redirect := range server.Redirects {
+		name := fmt.Sprintf("redirect%d", i)
+		fmt.Fprintf(&w, "\t@%s path_regexp %s %s\n", name, name, quoteString(redirect.From))
+	}
+	w.WriteString("\troute {\n")
+	for i, redirect := range server.Redirects {
+		name := fmt.Sprintf("redirect%d", i)
+		to := regexpGroupPattern.ReplaceAllString(redirect.To, "{re."+name+".$1}")
+		switch {
+		case redirect.Rewrite:
+			fmt.Fprintf(&w, "\t\trewrite @%s %s\n", name, quoteString(to))
+		case redirect.Code == 302:
+			fmt.Fprintf(&w, "\t\tredir @%s %s 302\n", name, quoteString(to))
+		default:
+			fmt.Fprintf(&w, "\t\tredir @%s %s 301\n", name, quoteString(to))
+		}
+	}
+	// Later headers replace earlier ones, and a prefix sorts first
+	locations, headers := serverLocations(website)
+	for _, location := range locations {
+		for _, name := range sortedKeys(headers[location]) {
+			fmt.Fprintf(&w, "\t\theader %s %s %s\n", caddyPathMatcher(location), name, quoteString(headers[location][name]))
+		}
+	}
+	if server.SPAFallback {
+		w.WriteString("\t\ttry_files {path} {path}/ /index.html\n")
+	}
+	w.WriteString("\t}\n")
+
+	if server.DirectoryListing {
+		w.WriteString("\tfile_server browse\n")
+	} else {
+		w.WriteString("\tfile_server\n")
+	}
+	if server.NotFoundPage != "" {
+		w.WriteString("\thandle_errors {\n")
+		w.WriteString("\t\t@notFound expression {err.status_code} == 404\n")
+		fmt.Fprintf(&w, "\t\troot * %s\n", root)
+		fmt.Fprintf(&w, "\t\trewrite @notFound %s\n", server.NotFoundPage)
+		w.WriteString("\t\tfile_server\n")
+		w.WriteString("\t}\n")
+	}
+	w.WriteString("}\n")
+	return w.String()
+}
+
+// caddyPathMatcher returns the path matcher of a prefix, which matches like
+// an nginx prefix location.
+func caddyPathMatcher(prefix string) string {
+	if prefix == "/" || prefix == "" {
+		return "*"
+	}
+	return prefix + "*"
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// ingress-nginx annotations turning an Ingress into a weighted canary of
+	// the Ingress with the same host.
+	canaryAnnotation       = "nginx.ingress.kubernetes.io/canary"
+	canaryWeightAnnotation = "nginx.ingress.kubernetes.io/canary-weight"
+)
+
+// canaryStrategy returns the canary settings of the website, or nil when new
+// revisions are rolled out another way.
+func canaryStrategy(website *myv1alpha1.Website) *myv1alpha1.CanaryStrategy {
+	strategy := website.Spec.Strategy
+	if strategy == nil || strategy.Type != myv1alpha1.CanaryWebsiteStrategy || strategy.Canary == nil || len(strategy.Canary.Steps) == 0 {
+		return nil
+	}
+	return strategy.Canary
+}
+
+// validateStrategy checks that spec.strategy can be rolled out: a canary
+// needs steps with weights between 0 and 100, and an Ingress to route by.
+func validateStrategy(website *myv1alpha1.Website) error {
+	strategy := website.Spec.Strategy
+	if strategy == nil {
+		return nil
+	}
+	switch strategy.Type {
+	case "", myv1alpha1.RollingWebsiteStrategy, myv1alpha1.BlueGreenWebsiteStrategy:
+		return nil
+	case myv1alpha1.CanaryWebsiteStrategy:
+	default:
+		return fmt.Errorf("spec.strategy.type: unknown strategy %q, expected %s, %s or %s", strategy.Type,
+			myv1alpha1.RollingWebsiteStrategy, myv1alpha1.CanaryWebsiteStrategy, myv1alpha1.BlueGreenWebsiteStrategy)
+	}
+
+	canary := strategy.Canary
+	if canary == nil || len(canary.Steps) == 0 {
+		return fmt.Errorf("spec.strategy.canary.steps: at least one step is required")
+	}
+	for i, step := range canary.Steps {
+		if step.Weight < 0 || step.Weight > 100 {
+			return fmt.Errorf("spec.strategy.canary.steps[%d].weight: %d is not between 0 and 100", i, step.Weight)
+		}
+		if step.Pause != nil && step.Pause.Duration < 0 {
+			return fmt.Errorf("spec.strategy.canary.steps[%d].pause: must not be negative", i)
+		}
+	}
+	switch canary.TrafficRouting {
+	case "", myv1alpha1.ReplicasCanaryRouting:
+	case myv1alpha1.IngressCanaryRouting:
+		if website.Spec.Ingress == nil {
+			return fmt.Errorf("spec.strategy.canary.trafficRouting: %s routing requires spec.ingress", myv1alpha1.IngressCanaryRouting)
+		}
+	default:
+		return fmt.Errorf("spec.strategy.canary.trafficRouting: unknown routing %q, expected %s or %s", canary.TrafficRouting,
+			myv1alpha1.ReplicasCanaryRouting, myv1alpha1.IngressCanaryRouting)
+	}
+	return nil
+}
+
+// canaryRouting returns how the traffic is split between stable and canary.
+func canaryRouting(website *myv1alpha1.Website, canary *myv1alpha1.CanaryStrategy) myv1alpha1.CanaryTrafficRouting {
+	if canary.TrafficRouting == myv1alpha1.IngressCanaryRouting && website.Spec.Ingress != nil {
+		return myv1alpha1.IngressCanaryRouting
+	}
+	return myv1alpha1.ReplicasCanaryRouting
+}
+
+// websiteReplicas returns the number of replicas the website asks for.
+func websiteReplicas(website *myv1alpha1.Website) int32 {
+	if website.Spec.Replicas != nil {
+		return *website.Spec.Replicas
+	}
+	return 1
+}
+
+// canaryName returns the name of the canary Deployment, Service and Ingress.
+func canaryName(website *myv1alpha1.Website) string {
+	return website.Spec.DeploymentName + "-canary"
+}
+
+// splitReplicas splits the website replicas between stable and canary for
+// weight. Both keep at least one replica while the canary runs, so the split
+// is only as fine grained as the number of replicas allows.
+func splitReplicas(total, weight int32) (int32, int32) {
+	canary := int32(math.Round(float64(total) * float64(weight) / 100))
+	if canary < 1 {
+		canary = 1
+	}
+	stable := total - canary
+	if stable < 1 {
+		stable = 1
+	}
+	return stable, canary
+}
+
+// syncCanary runs the canary release of candidate next to the stable
+// Deployment, stepping through the canary weights while the canary stays
+// ready. It returns the revision and replicas the stable Deployment should
+// run: the current stable revision while the canary is tested, candidate
+// once it is promoted.
+func (c *Controller) syncCanary(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, candidate, triggeredBy string, stable *appsv1.Deployment) (string, *int32, error) {
+	canary := canaryStrategy(website)
+	// There is nothing to test on the first rollout, for a rollback or when
+	// the stable pods already serve the candidate.
+	if canary == nil || stable == nil || status.Revision == "" || candidate == "" || candidate == status.Revision || triggeredBy == triggeredByRollback {
+		return candidate, website.Spec.Replicas, c.finishCanary(website, status, candidate, stable)
+	}
+
+	if status.Canary != nil && status.Canary.Revision == candidate && status.Canary.Phase == myv1alpha1.CanaryAborted {
+		// Do not try an aborted revision again until the branch moves on.
+		return status.Revision, website.Spec.Replicas, c.deleteCanary(website)
+	}
+	if status.Canary == nil || status.Canary.Revision != candidate || status.Canary.Phase == myv1alpha1.CanaryPromoted {
+		status.Canary = &myv1alpha1.CanaryStatus{
+			Revision: candidate,
+			Phase:    myv1alpha1.CanaryProgressing,
+			Weight:   canary.Steps[0].Weight,
+		}
+		c.recorder.Eventf(website, corev1.EventTypeNormal, CanaryStarted, MessageCanaryStarted, candidate, status.Revision)
+	}
+	if status.Canary.Phase == myv1alpha1.CanaryPromoting {
+		return candidate, website.Spec.Replicas, nil
+	}
+
+	clampCanaryStep(status.Canary, len(canary.Steps))
+	step := canary.Steps[status.Canary.Step]
+	status.Canary.Weight = step.Weight
+	total := websiteReplicas(website)
+	stableReplicas, canaryReplicas := splitReplicas(total, step.Weight)
+	routing := canaryRouting(website, canary)
+	if routing == myv1alpha1.IngressCanaryRouting {
+		// the Ingress splits the traffic, the stable pods keep full capacity
+		stableReplicas = total
+		if _, err := c.syncService(website, newCanaryService(website)); err != nil {
+			return status.Revision, website.Spec.Replicas, err
+		}
+		if _, err := c.syncIngressObject(website, newCanaryIngress(website, step.Weight)); err != nil {
+			return status.Revision, website.Spec.Replicas, err
+		}
+	}
+
+	canaryDeployment, err := c.syncDeployment(website, newCanaryDeployment(website, candidate, canaryReplicas))
+	if err != nil {
+		return status.Revision, website.Spec.Replicas, err
+	}
+
+	switch {
+	case deploymentFailed(canaryDeployment):
+		return status.Revision, website.Spec.Replicas, c.abortCanary(website, status, "canary did not become ready within its progress deadline")
+	case !deploymentComplete(canaryDeployment):
+		if status.Canary.Phase == myv1alpha1.CanaryPaused {
+			return status.Revision, website.Spec.Replicas, c.abortCanary(website, status, "canary pods became unavailable")
+		}
+		status.Canary.Message = fmt.Sprintf("waiting for %d canary replicas to become ready", canaryReplicas)
+		return status.Revision, &stableReplicas, nil
+	}
+
+	now := metav1.Now()
+	if status.Canary.StepStartTime == nil {
+		status.Canary.Phase = myv1alpha1.CanaryPaused
+		status.Canary.StepStartTime = &now
+		status.Canary.Message = ""
+		c.recorder.Eventf(website, corev1.EventTypeNormal, CanaryStep, MessageCanaryStep, status.Canary.Step+1, len(canary.Steps), step.Weight)
+	}
+	var pause time.Duration
+	if step.Pause != nil {
+		pause = step.Pause.Duration
+	}
+	if remaining := pause - now.Sub(status.Canary.StepStartTime.Time); remaining > 0 {
+		c.enqueueWebsiteAfter(website, remaining)
+		return status.Revision, &stableReplicas, nil
+	}
+
+	if int(status.Canary.Step)+1 < len(canary.Steps) {
+		status.Canary.Step++
+		status.Canary.Weight = canary.Steps[status.Canary.Step].Weight
+		status.Canary.Phase = myv1alpha1.CanaryProgressing
+		status.Canary.StepStartTime = nil
+		c.enqueueWebsiteAfter(website, 0)
+		return status.Revision, &stableReplicas, nil
+	}
+
+	// All steps passed, roll the stable pods out to the canary revision. The
+	// canary keeps serving until they are done, see finishCanary.
+	status.Canary.Phase = myv1alpha1.CanaryPromoting
+	status.Canary.Message = ""
+	c.recorder.Eventf(website, corev1.EventTypeNormal, CanaryPromoting, MessageCanaryPromoting, candidate)
+	return candidate, website.Spec.Replicas, nil
+}
+
+// clampCanaryStep keeps the step of a canary within its steps. When they
+// were shortened under a running canary, it goes on from the last one left.
+func clampCanaryStep(status *myv1alpha1.CanaryStatus, steps int) {
+	step := status.Step
+	if last := int32(steps) - 1; step > last {
+		step = last
+	}
+	if step < 0 {
+		step = 0
+	}
+	if step != status.Step {
+		status.Step = step
+		status.StepStartTime = nil
+	}
+}
+
+// finishCanary removes the canary once the stable pods serve its revision,
+// and aborts a canary whose revision is not wanted any longer.
+func (c *Controller) finishCanary(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, revision string, stable *appsv1.Deployment) error {
+	if status.Canary == nil {
+		return c.deleteCanary(website)
+	}
+	switch status.Canary.Phase {
+	case myv1alpha1.CanaryPromoting:
+		if status.Canary.Revision == revision {
+			if stable == nil || stable.Spec.Template.Annotations[revisionAnnotation] != revision || !deploymentComplete(stable) {
+				// keep the canary serving until the stable pods took over
+				return nil
+			}
+			status.Canary.Phase = myv1alpha1.CanaryPromoted
+			c.recorder.Eventf(website, corev1.EventTypeNormal, CanaryPromoted, MessageCanaryPromoted, revision)
+			return c.deleteCanary(website)
+		}
+		return c.abortCanary(website, status, "superseded by revision "+revision)
+	case myv1alpha1.CanaryProgressing, myv1alpha1.CanaryPaused:
+		return c.abortCanary(website, status, "superseded by revision "+revision)
+	}
+	return c.deleteCanary(website)
+}
+
+// abortCanary removes the canary and records why it was aborted.
+func (c *Controller) abortCanary(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, reason string) error {
+	status.Canary.Phase = myv1alpha1.CanaryAborted
+	status.Canary.Message = reason
+	status.Canary.StepStartTime = nil
+	c.recorder.Eventf(website, corev1.EventTypeWarning, CanaryAborted, MessageCanaryAborted, status.Canary.Revision, reason)
+	return c.deleteCanary(website)
+}
+
+// deleteCanary deletes the canary Deployment, Service and Ingress the website
+// controls, if any.
+func (c *Controller) deleteCanary(website *myv1alpha1.Website) error {
+	name := canaryName(website)
+	if err := c.deleteDeployment(website, name); err != nil {
+		return err
+	}
+	serviceName := name + "-npsvc"
+	if service, err := c.servicesLister.Services(website.Namespace).Get(serviceName); err == nil && metav1.IsControlledBy(service, website) {
+		if err := c.kubeclientset.CoreV1().Services(website.Namespace).Delete(serviceName, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return c.deleteIngress(website, name)
+}
+
+// deploymentFailed tells whether the Deployment gave up rolling out its
+// latest pod template after exceeding its progress deadline.
+func deploymentFailed(deployment *appsv1.Deployment) bool {
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	for _, cond := range deployment.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Status == corev1.ConditionFalse && cond.Reason == "ProgressDeadlineExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+// canaryLabels returns the labels of the canary pods. With replica based
+// routing they are selected by the website service next to the stable pods,
+// with Ingress based routing only by the canary service.
+func canaryLabels(website *myv1alpha1.Website) map[string]string {
+	canary := canaryStrategy(website)
+	if canary != nil && canaryRouting(website, canary) == myv1alpha1.IngressCanaryRouting {
+		return map[string]string{
+			"app":        "website-nginx-canary",
+			"controller": website.Name,
+		}
+	}
+	return map[string]string{
+		"app":        "website-nginx",
+		"controller": website.Name,
+		"track":      "canary",
+	}
+}
+
+// newCanaryDeployment creates the Deployment running the canary revision.
+func newCanaryDeployment(website *myv1alpha1.Website, revision string, replicas int32) *appsv1.Deployment {
+	deployment := newDeployment(website, revision)
+	labels := canaryLabels(website)
+	deployment.Name = canaryName(website)
+	deployment.Spec.Replicas = &replicas
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	deployment.Spec.Template.Labels = labels
+	return deployment
+}
+
+// newCanaryService creates the Service selecting only the canary pods, used
+// as backend of the canary Ingress.
+func newCanaryService(website *myv1alpha1.Website) *corev1.Service {
+	service := newService(website)
+	service.Name = canaryName(website) + "-npsvc"
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	service.Spec.Selector = canaryLabels(website)
+	return service
+}
+
+// newCanaryIngress creates the Ingress sending weight percent of the traffic
+// of the website host to the canary pods.
+func newCanaryIngress(website *myv1alpha1.Website, weight int32) *networkingv1beta1.Ingress {
+	ingress := newIngress(website)
+	ingress.Name = canaryName(website)
+	if ingress.Annotations == nil {
+		ingress.Annotations = map[string]string{}
+	}
+	ingress.Annotations[canaryAnnotation] = "true"
+	ingress.Annotations[canaryWeightAnnotation] = fmt.Sprintf("%d", weight)
+	ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName = canaryName(website) + "-npsvc"
+	return ingress
+}
+
+// enqueueWebsiteAfter puts the website back on the work queue after delay.
+func (c *Controller) enqueueWebsiteAfter(website *myv1alpha1.Website, delay time.Duration) {
+	key, err := websiteKey(website)
+	if err != nil {
+		return
+	}
+	c.workqueue.AddAfter(key, delay)
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	networkinglisters "k8s.io/client-go/listers/networking/v1beta1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+func TestValidateStrategy(t *testing.T) {
+	canary := func(routing myv1alpha1.CanaryTrafficRouting, weights ...int32) *myv1alpha1.WebsiteStrategy {
+		strategy := &myv1alpha1.WebsiteStrategy{
+			Type:   myv1alpha1.CanaryWebsiteStrategy,
+			Canary: &myv1alpha1.CanaryStrategy{TrafficRouting: routing},
+		}
+		for _, weight := range weights {
+			strategy.Canary.Steps = append(strategy.Canary.Steps, myv1alpha1.CanaryStep{Weight: weight})
+		}
+		return strategy
+	}
+	tests := []struct {
+		name     string
+		strategy *myv1alpha1.WebsiteStrategy
+		ingress  bool
+		wantErr  bool
+	}{
+		{name: "default"},
+		{name: "rolling", strategy: &myv1alpha1.WebsiteStrategy{Type: myv1alpha1.RollingWebsiteStrategy}},
+		{name: "blue/green", strategy: &myv1alpha1.WebsiteStrategy{Type: myv1alpha1.BlueGreenWebsiteStrategy}},
+		{name: "canary", strategy: canary("", 0, 20, 100)},
+		{name: "ingress canary", strategy: canary(myv1alpha1.IngressCanaryRouting, 10), ingress: true},
+		{name: "unknown type", strategy: &myv1alpha1.WebsiteStrategy{Type: "Shadow"}, wantErr: true},
+		{name: "canary without settings", strategy: &myv1alpha1.WebsiteStrategy{Type: myv1alpha1.CanaryWebsiteStrategy}, wantErr: true},
+		{name: "canary without steps", strategy: canary(""), wantErr: true},
+		{name: "weight above 100", strategy: canary("", 50, 101), wantErr: true},
+		{name: "negative weight", strategy: canary("", -1), wantErr: true},
+		{name: "ingress canary without ingress", strategy: canary(myv1alpha1.IngressCanaryRouting, 10), wantErr: true},
+		{name: "unknown routing", strategy: canary("Mesh", 10), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			website := &myv1alpha1.Website{Spec: myv1alpha1.WebsiteSpec{Strategy: tt.strategy}}
+			if tt.ingress {
+				website.Spec.Ingress = &myv1alpha1.WebsiteIngress{Host: "kubia.example.com"}
+			}
+			if err := validateStrategy(website); (err != nil) != tt.wantErr {
+				t.Errorf("validateStrategy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClampCanaryStep(t *testing.T) {
+	started := metav1.Now()
+	tests := []struct {
+		name      string
+		step      int32
+		steps     int
+		want      int32
+		wantReset bool
+	}{
+		{name: "within steps", step: 1, steps: 3, want: 1},
+		{name: "last step", step: 2, steps: 3, want: 2},
+		{name: "steps shortened", step: 4, steps: 2, want: 1, wantReset: true},
+		{name: "negative step", step: -1, steps: 2, want: 0, wantReset: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &myv1alpha1.CanaryStatus{Step: tt.step, StepStartTime: &started}
+			clampCanaryStep(status, tt.steps)
+			if status.Step != tt.want {
+				t.Errorf("Step = %d, want %d", status.Step, tt.want)
+			}
+			if reset := status.StepStartTime == nil; reset != tt.wantReset {
+				t.Errorf("StepStartTime reset = %v, want %v", reset, tt.wantReset)
+			}
+		})
+	}
+}
+
+// readyDeployment marks all replicas of deployment as rolled out and
+// available.
+func readyDeployment(deployment *appsv1.Deployment) *appsv1.Deployment {
+	deployment.Generation = 1
+	deployment.Status.ObservedGeneration = 1
+	deployment.Status.Replicas = *deployment.Spec.Replicas
+	deployment.Status.UpdatedReplicas = *deployment.Spec.Replicas
+	deployment.Status.AvailableReplicas = *deployment.Spec.Replicas
+	return deployment
+}
+
+func TestSyncCanary(t *testing.T) {
+	const (
+		stableRevision = "1111111111111111111111111111111111111111"
+		candidate      = "2222222222222222222222222222222222222222"
+	)
+	hour := &metav1.Duration{Duration: time.Hour}
+	newWebsite := func(routing myv1alpha1.CanaryTrafficRouting) *myv1alpha1.Website {
+		replicas := int32(4)
+		website := &myv1alpha1.Website{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia", UID: types.UID("kubia-uid")},
+			Spec: myv1alpha1.WebsiteSpec{
+				DeploymentName: "kubia",
+				GitRepo:        "https://github.com/nevermosby/kubia-website-example.git",
+				Replicas:       &replicas,
+				Strategy: &myv1alpha1.WebsiteStrategy{
+					Type: myv1alpha1.CanaryWebsiteStrategy,
+					Canary: &myv1alpha1.CanaryStrategy{
+						TrafficRouting: routing,
+						Steps:          []myv1alpha1.CanaryStep{{Weight: 25, Pause: hour}, {Weight: 50, Pause: hour}},
+					},
+				},
+			},
+		}
+		if routing == myv1alpha1.IngressCanaryRouting {
+			website.Spec.Ingress = &myv1alpha1.WebsiteIngress{Host: "kubia.example.com"}
+		}
+		return website
+	}
+	started := metav1.NewTime(time.Now().Add(-time.Minute))
+	elapsed := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+
+	tests := []struct {
+		name    string
+		routing myv1alpha1.CanaryTrafficRouting
+		canary  *myv1alpha1.CanaryStatus
+		// the canary Deployment running: none when canaryReplicas is 0
+		canaryReplicas int32
+		canaryState    string
+
+		wantRevision string
+		wantReplicas int32
+		wantPhase    myv1alpha1.CanaryPhase
+		wantStep     int32
+		wantWeight   int32
+		// the canary Deployment left: none when wantCanary is 0
+		wantCanary int32
+		wantEvent  string
+	}{
+		{
+			name:         "start",
+			wantRevision: stableRevision, wantReplicas: 3,
+			wantPhase: myv1alpha1.CanaryProgressing, wantWeight: 25,
+			wantCanary: 1, wantEvent: CanaryStarted,
+		},
+		{
+			name:           "waiting for the canary",
+			canary:         &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryProgressing, Step: 1, Weight: 50},
+			canaryReplicas: 2, canaryState: "progressing",
+			wantRevision: stableRevision, wantReplicas: 2,
+			wantPhase: myv1alpha1.CanaryProgressing, wantStep: 1, wantWeight: 50,
+			wantCanary: 2,
+		},
+		{
+			name:           "pause once ready",
+			canary:         &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryProgressing, Weight: 25},
+			canaryReplicas: 1, canaryState: "ready",
+			wantRevision: stableRevision, wantReplicas: 3,
+			wantPhase: myv1alpha1.CanaryPaused, wantWeight: 25,
+			wantCanary: 1, wantEvent: CanaryStep,
+		},
+		{
+			name:           "paused",
+			canary:         &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryPaused, Weight: 25, StepStartTime: &started},
+			canaryReplicas: 1, canaryState: "ready",
+			wantRevision: stableRevision, wantReplicas: 3,
+			wantPhase: myv1alpha1.CanaryPaused, wantWeight: 25,
+			wantCanary: 1,
+		},
+		{
+			name:           "advance after the pause",
+			canary:         &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryPaused, Weight: 25, StepStartTime: &elapsed},
+			canaryReplicas: 1, canaryState: "ready",
+			wantRevision: stableRevision, wantReplicas: 3,
+			wantPhase: myv1alpha1.CanaryProgressing, wantStep: 1, wantWeight: 50,
+			wantCanary: 1,
+		},
+		{
+			name:           "promote after the last step",
+			canary:         &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryPaused, Step: 1, Weight: 50, StepStartTime: &elapsed},
+			canaryReplicas: 2, canaryState: "ready",
+			wantRevision: candidate, wantReplicas: 4,
+			wantPhase: myv1alpha1.CanaryPromoting, wantStep: 1, wantWeight: 50,
+			wantCanary: 2, wantEvent: CanaryPromoting,
+		},
+		{
+			name:           "promoting keeps the canary",
+			canary:         &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryPromoting, Step: 1, Weight: 50},
+			canaryReplicas: 2, canaryState: "ready",
+			wantRevision: candidate, wantReplicas: 4,
+			wantPhase: myv1alpha1.CanaryPromoting, wantStep: 1, wantWeight: 50,
+			wantCanary: 2,
+		},
+		{
+			name:           "abort past the progress deadline",
+			canary:         &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryProgressing, Weight: 25},
+			canaryReplicas: 1, canaryState: "failed",
+			wantRevision: stableRevision, wantReplicas: 4,
+			wantPhase: myv1alpha1.CanaryAborted, wantWeight: 25,
+			wantEvent: CanaryAborted,
+		},
+		{
+			name:           "abort when paused pods become unavailable",
+			canary:         &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryPaused, Weight: 25, StepStartTime: &started},
+			canaryReplicas: 1, canaryState: "progressing",
+			wantRevision: stableRevision, wantReplicas: 4,
+			wantPhase: myv1alpha1.CanaryAborted, wantWeight: 25,
+			wantEvent: CanaryAborted,
+		},
+		{
+			name:         "aborted revision is not tried again",
+			canary:       &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryAborted, Weight: 25},
+			wantRevision: stableRevision, wantReplicas: 4,
+			wantPhase: myv1alpha1.CanaryAborted, wantWeight: 25,
+		},
+		{
+			name:           "ingress routing keeps the stable capacity",
+			routing:        myv1alpha1.IngressCanaryRouting,
+			canary:         &myv1alpha1.CanaryStatus{Revision: candidate, Phase: myv1alpha1.CanaryProgressing, Weight: 25},
+			canaryReplicas: 1, canaryState: "progressing",
+			wantRevision: stableRevision, wantReplicas: 4,
+			wantPhase: myv1alpha1.CanaryProgressing, wantWeight: 25,
+			wantCanary: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			website := newWebsite(tt.routing)
+			stable := readyDeployment(newDeployment(website, stableRevision))
+			objects := []runtime.Object{stable}
+			if tt.canaryReplicas > 0 {
+				canary := newCanaryDeployment(website, candidate, tt.canaryReplicas)
+				switch tt.canaryState {
+				case "ready":
+					readyDeployment(canary)
+				case "failed":
+					canary.Status.Conditions = []appsv1.DeploymentCondition{{
+						Type:   appsv1.DeploymentProgressing,
+						Status: corev1.ConditionFalse,
+						Reason: "ProgressDeadlineExceeded",
+					}}
+				}
+				objects = append(objects, canary)
+			}
+			deployments := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, obj := range objects {
+				if err := deployments.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			client := fake.NewSimpleClientset(objects...)
+			recorder := record.NewFakeRecorder(10)
+			c := &Controller{
+				kubeclientset:     client,
+				deploymentsLister: appslisters.NewDeploymentLister(deployments),
+				servicesLister:    corelisters.NewServiceLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+				ingressesLister:   networkinglisters.NewIngressLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+				workqueue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Websites"),
+				recorder:          recorder,
+			}
+			defer c.workqueue.ShutDown()
+
+			status := &myv1alpha1.WebsiteStatus{Revision: stableRevision, Canary: tt.canary}
+			revision, replicas, err := c.syncCanary(website, status, candidate, "", stable)
+			if err != nil {
+				t.Fatalf("syncCanary() error = %v", err)
+			}
+			if revision != tt.wantRevision {
+				t.Errorf("stable revision = %s, want %s", revision, tt.wantRevision)
+			}
+			if replicas == nil || *replicas != tt.wantReplicas {
+				t.Errorf("stable replicas = %v, want %d", replicas, tt.wantReplicas)
+			}
+			if got := status.Canary; got.Revision != candidate || got.Phase != tt.wantPhase || got.Step != tt.wantStep || got.Weight != tt.wantWeight {
+				t.Errorf("canary status = %+v, want phase %s at step %d with weight %d", got, tt.wantPhase, tt.wantStep, tt.wantWeight)
+			}
+
+			canary, err := client.AppsV1().Deployments("default").Get(canaryName(website), metav1.GetOptions{})
+			switch {
+			case tt.wantCanary == 0:
+				if !errors.IsNotFound(err) {
+					t.Errorf("canary Deployment = %v, %v, want it deleted", canary, err)
+				}
+			case err != nil:
+				t.Errorf("canary Deployment: %v", err)
+			default:
+				if *canary.Spec.Replicas != tt.wantCanary {
+					t.Errorf("canary replicas = %d, want %d", *canary.Spec.Replicas, tt.wantCanary)
+				}
+				track, routed := canary.Spec.Template.Labels["track"]
+				if wantTrack := tt.routing != myv1alpha1.IngressCanaryRouting; routed != wantTrack || (routed && track != "canary") {
+					t.Errorf("canary pod labels = %v, want track: canary %v", canary.Spec.Template.Labels, wantTrack)
+				}
+			}
+			if tt.routing == myv1alpha1.IngressCanaryRouting {
+				ingress, err := client.NetworkingV1beta1().Ingresses("default").Get(canaryName(website), metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("canary Ingress: %v", err)
+				}
+				if got, want := ingress.Annotations[canaryWeightAnnotation], fmt.Sprint(tt.wantWeight); got != want {
+					t.Errorf("canary Ingress weight = %s, want %s", got, want)
+				}
+			}
+
+			var event string
+			select {
+			case event = <-recorder.Events:
+			default:
+			}
+			if tt.wantEvent == "" && event != "" || tt.wantEvent != "" && !strings.Contains(event, " "+tt.wantEvent+" ") {
+				t.Errorf("event %q, want %q", event, tt.wantEvent)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// clusterWebsiteKind marks the Website view of a ClusterWebsite, see
+// clusterWebsiteView.
+const clusterWebsiteKind = "ClusterWebsite"
+
+// clusterWebsiteView returns the Website a ClusterWebsite is reconciled as,
+// living in its target namespace. The syncHandler handles both kinds alike,
+// so they produce identical children; only the owner references, events and
+// status updates go to the ClusterWebsite, which the view's kind tells.
+func clusterWebsiteView(clusterWebsite *myv1alpha1.ClusterWebsite) *myv1alpha1.Website {
+	return &myv1alpha1.Website{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: myv1alpha1.SchemeGroupVersion.String(),
+			Kind:       clusterWebsiteKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              clusterWebsite.Name,
+			Namespace:         clusterWebsite.Spec.TargetNamespace,
+			UID:               clusterWebsite.UID,
+			ResourceVersion:   clusterWebsite.ResourceVersion,
+			Generation:        clusterWebsite.Generation,
+			CreationTimestamp: clusterWebsite.CreationTimestamp,
+			Labels:            clusterWebsite.Labels,
+			Annotations:       clusterWebsite.Annotations,
+		},
+		Spec:   *clusterWebsite.Spec.WebsiteSpec.DeepCopy(),
+		Status: *clusterWebsite.Status.DeepCopy(),
+	}
+}
+
+// isClusterWebsite returns whether website is the view of a ClusterWebsite.
+func isClusterWebsite(website *myv1alpha1.Website) bool {
+	return website.Kind == clusterWebsiteKind
+}
+
+// websiteControllerRef returns the controller reference the children of
+// website carry, to the Website or ClusterWebsite.
+func websiteControllerRef(website *myv1alpha1.Website) *metav1.OwnerReference {
+	kind := "Website"
+	if isClusterWebsite(website) {
+		kind = clusterWebsiteKind
+	}
+	return metav1.NewControllerRef(website, myv1alpha1.SchemeGroupVersion.WithKind(kind))
+}
+
+// websiteKey returns the workqueue key of website: namespace/name for a
+// Website, the bare name for a ClusterWebsite.
+func websiteKey(website *myv1alpha1.Website) (string, error) {
+	if isClusterWebsite(website) {
+		return website.Name, nil
+	}
+	return cache.MetaNamespaceKeyFunc(website)
+}
+
+// podDeployment returns the Deployment whose ReplicaSet controls pod, nil
+// when it is not found. The ReplicaSet is named after the Deployment and the
+// pod-template-hash label of its pods.
+func (c *Controller) podDeployment(pod *corev1.Pod) *appsv1.Deployment {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil || ref.Kind != "ReplicaSet" {
+		return nil
+	}
+	name := strings.TrimSuffix(ref.Name, "-"+pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey])
+	deployment, err := c.deploymentsLister.Deployments(pod.Namespace).Get(name)
+	if err != nil {
+		return nil
+	}
+	return deployment
+}
+
+// podWebsiteKey returns the workqueue key of the Website or ClusterWebsite
+// whose Deployment runs pod, "" when it runs no website.
+func (c *Controller) podWebsiteKey(pod *corev1.Pod) string {
+	deployment := c.podDeployment(pod)
+	if deployment == nil {
+		return ""
+	}
+	ref := metav1.GetControllerOf(deployment)
+	switch {
+	case ref == nil || ref.APIVersion != myv1alpha1.SchemeGroupVersion.String():
+		return ""
+	case ref.Kind == clusterWebsiteKind:
+		return ref.Name
+	case ref.Kind == "Website":
+		return deployment.Namespace + "/" + ref.Name
+	}
+	return ""
+}
+
+// websitePods returns the pods of the Deployments of website. A
+// ClusterWebsite shares the controller label of its pods with a Website of
+// the same name in its target namespace, so the pods are told apart by the
+// owner of their Deployment.
+func (c *Controller) websitePods(website *myv1alpha1.Website) ([]*corev1.Pod, error) {
+	pods, err := c.podsLister.Pods(website.Namespace).List(labels.SelectorFromSet(labels.Set{"controller": website.Name}))
+	if err != nil {
+		return nil, err
+	}
+	owned := make([]*corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if deployment := c.podDeployment(pod); deployment != nil && metav1.IsControlledBy(deployment, website) {
+			owned = append(owned, pod)
+		}
+	}
+	return owned, nil
+}
+
+// websiteOf returns the Website of an informer object, the view of a
+// ClusterWebsite, so index functions serve both kinds.
+func websiteOf(obj interface{}) (*myv1alpha1.Website, bool) {
+	switch object := obj.(type) {
+	case *myv1alpha1.Website:
+		return object, true
+	case *myv1alpha1.ClusterWebsite:
+		return clusterWebsiteView(object), true
+	}
+	return nil, false
+}
+
+// websiteEventRecorder records the events of ClusterWebsite views on the
+// ClusterWebsite itself rather than on a Website of its target namespace.
+type websiteEventRecorder struct {
+	record.EventRecorder
+}
+
+func (r websiteEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.EventRecorder.Event(eventObject(object), eventtype, reason, message)
+}
+
+func (r websiteEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.EventRecorder.Eventf(eventObject(object), eventtype, reason, messageFmt, args...)
+}
+
+// eventObject returns the object an event about object is recorded on.
+func eventObject(object runtime.Object) runtime.Object {
+	website, ok := object.(*myv1alpha1.Website)
+	if !ok || !isClusterWebsite(website) {
+		return object
+	}
+	return &myv1alpha1.ClusterWebsite{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            website.Name,
+			UID:             website.UID,
+			ResourceVersion: website.ResourceVersion,
+		},
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// addDeploymentPod adds deployment and a pod of it, owned through a
+// ReplicaSet like the Deployment controller does, to the indexers.
+func addDeploymentPod(t *testing.T, deployments, pods cache.Indexer, deployment *appsv1.Deployment) *corev1.Pod {
+	t.Helper()
+	deployment.UID = types.UID(deployment.Name + "-uid")
+	if err := deployments.Add(deployment); err != nil {
+		t.Fatal(err)
+	}
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: deployment.Name + "-5d8f7c9b6", UID: types.UID(deployment.Name + "-rs-uid")}}
+	replicaSet.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))}
+	labels := map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "5d8f7c9b6"}
+	for k, v := range deployment.Spec.Template.Labels {
+		labels[k] = v
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       deployment.Namespace,
+		Name:            replicaSet.Name + "-x7k2p",
+		Labels:          labels,
+		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(replicaSet, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))},
+	}}
+	if err := pods.Add(pod); err != nil {
+		t.Fatal(err)
+	}
+	return pod
+}
+
+func TestWebsitePodsSelectedByOwner(t *testing.T) {
+	website := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia", UID: types.UID("website-uid")},
+		Spec:       myv1alpha1.WebsiteSpec{DeploymentName: "kubia"},
+	}
+	clusterWebsite := clusterWebsiteView(&myv1alpha1.ClusterWebsite{
+		ObjectMeta: metav1.ObjectMeta{Name: "docs", UID: types.UID("cluster-website-uid")},
+		Spec: myv1alpha1.ClusterWebsiteSpec{
+			TargetNamespace: "default",
+			WebsiteSpec:     myv1alpha1.WebsiteSpec{DeploymentName: "docs"},
+		},
+	})
+
+	deployments := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	addDeploymentPod(t, deployments, pods, newDeployment(website, ""))
+	addDeploymentPod(t, deployments, pods, newDeployment(clusterWebsite, ""))
+	// a Deployment the website does not own, whose pods carry its labels
+	stray := newDeployment(website, "")
+	stray.Name = "kubia-legacy"
+	stray.OwnerReferences = nil
+	strayPod := addDeploymentPod(t, deployments, pods, stray)
+	c := &Controller{
+		deploymentsLister: appslisters.NewDeploymentLister(deployments),
+		podsLister:        corelisters.NewPodLister(pods),
+	}
+
+	for _, tt := range []struct {
+		website *myv1alpha1.Website
+		pod     string
+		key     string
+	}{
+		{website: website, pod: "kubia-5d8f7c9b6-x7k2p", key: "default/kubia"},
+		{website: clusterWebsite, pod: "docs-5d8f7c9b6-x7k2p", key: "docs"},
+	} {
+		got, err := c.websitePods(tt.website)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Name != tt.pod {
+			t.Errorf("pods of %s = %v, want only %s", websiteDescription(tt.website), got, tt.pod)
+			continue
+		}
+		if key := c.podWebsiteKey(got[0]); key != tt.key {
+			t.Errorf("podWebsiteKey(%s) = %q, want %q", tt.pod, key, tt.key)
+		}
+	}
+	if key := c.podWebsiteKey(strayPod); key != "" {
+		t.Errorf("podWebsiteKey(%s) = %q, want none", strayPod.Name, key)
+	}
+}
+
+func TestClusterWebsiteNameClash(t *testing.T) {
+	uniqueIndexers := cache.Indexers{
+		websiteNameIndex:    uniqueIndexFunc(websiteNameIndex),
+		deploymentNameIndex: uniqueIndexFunc(deploymentNameIndex),
+		serviceNameIndex:    uniqueIndexFunc(serviceNameIndex),
+		hostIndex:           uniqueIndexFunc(hostIndex),
+	}
+	websites := cache.NewIndexer(cache.MetaNamespaceKeyFunc, uniqueIndexers)
+	clusterWebsites := cache.NewIndexer(cache.MetaNamespaceKeyFunc, uniqueIndexers)
+	c := &Controller{
+		websitesIndexer:        websites,
+		clusterWebsitesIndexer: clusterWebsites,
+		recorder:               websiteEventRecorder{record.NewFakeRecorder(10)},
+	}
+
+	website := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia", CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))},
+		Spec:       myv1alpha1.WebsiteSpec{DeploymentName: "kubia"},
+	}
+	// same name, other Deployment, Service and host
+	clusterWebsite := &myv1alpha1.ClusterWebsite{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubia", CreationTimestamp: metav1.Now()},
+		Spec: myv1alpha1.ClusterWebsiteSpec{
+			TargetNamespace: "default",
+			WebsiteSpec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia-cluster"},
+		},
+	}
+	if err := websites.Add(website); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterWebsites.Add(clusterWebsite); err != nil {
+		t.Fatal(err)
+	}
+
+	view := clusterWebsiteView(clusterWebsite)
+	err := c.admitUnique(view, nil)
+	if err == nil || !strings.Contains(err.Error(), `name "kubia" is used by Website default/kubia`) {
+		t.Errorf("admitUnique() = %v, want the name clash with the Website", err)
+	}
+
+	status := &myv1alpha1.WebsiteStatus{}
+	conflicts, err := c.syncConflicts(view, status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || getWebsiteCondition(*status, myv1alpha1.WebsiteConflict) == nil {
+		t.Errorf("the later ClusterWebsite got conflicts %v, status %+v, want the name", conflicts, status)
+	}
+	status = &myv1alpha1.WebsiteStatus{}
+	if conflicts, err := c.syncConflicts(website, status); err != nil || len(conflicts) > 0 {
+		t.Errorf("the older Website got conflicts %v, %v, want none", conflicts, err)
+	}
+
+	// in another namespace the name is free
+	view.Namespace = "docs"
+	if err := c.admitUnique(view, nil); err != nil {
+		t.Errorf("admitUnique() in another namespace = %v", err)
+	}
+}
@@ -1,5 +1,5 @@
 
 ./vendor/k8s.io/code-generator/generate-groups.sh "deepcopy,client,informer,lister" \
 github.com/nevermosby/my-crd-controller/pkg/client \
-github.com/nevermosby/my-crd-controller/pkg/apis "mycontroller:v1alpha1" \
+github.com/nevermosby/my-crd-controller/pkg/apis "mycontroller:v1alpha1,v1beta1" \
 --go-header-file /Users/davidli/gh/myk8scrd/src/github.com/nevermosby/my-crd-controller/hack/custom-boilerplate.go.txt
\ No newline at end of file
@@ -0,0 +1,63 @@
+package main
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// newWebsiteCondition creates a new website condition.
+func newWebsiteCondition(condType myv1alpha1.WebsiteConditionType, status corev1.ConditionStatus, reason, message string) myv1alpha1.WebsiteCondition {
+	return myv1alpha1.WebsiteCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// getWebsiteCondition returns the condition with the provided type.
+func getWebsiteCondition(status myv1alpha1.WebsiteStatus, condType myv1alpha1.WebsiteConditionType) *myv1alpha1.WebsiteCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == condType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// setWebsiteCondition updates the website status to include the provided
+// condition. If the condition that we are about to add already exists and
+// has the same status and reason then we are not going to update.
+func setWebsiteCondition(status *myv1alpha1.WebsiteStatus, condition myv1alpha1.WebsiteCondition) {
+	currentCond := getWebsiteCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason && currentCond.Message == condition.Message {
+		return
+	}
+	// Do not update lastTransitionTime if the status of the condition doesn't change.
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	newConditions := filterOutCondition(status.Conditions, condition.Type)
+	status.Conditions = append(newConditions, condition)
+}
+
+// removeWebsiteCondition removes the website condition with the provided type.
+func removeWebsiteCondition(status *myv1alpha1.WebsiteStatus, condType myv1alpha1.WebsiteConditionType) {
+	status.Conditions = filterOutCondition(status.Conditions, condType)
+}
+
+// filterOutCondition returns a new slice of website conditions without
+// conditions with the provided type.
+func filterOutCondition(conditions []myv1alpha1.WebsiteCondition, condType myv1alpha1.WebsiteConditionType) []myv1alpha1.WebsiteCondition {
+	var newConditions []myv1alpha1.WebsiteCondition
+	for _, c := range conditions {
+		if c.Type == condType {
+			continue
+		}
+		newConditions = append(newConditions, c)
+	}
+	return newConditions
+}
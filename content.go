@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// contentCheckTTL is how long the result of a content path check is
+	// kept. A commit never changes, so results only age out to bound
+	// memory.
+	contentCheckTTL = 24 * time.Hour
+
+	// contentPathAnnotation on the pod template records a hash of the
+	// content path served with the stock nginx config, which only reaches
+	// the pods in the command of the server, so a changed one is rolled out.
+	contentPathAnnotation = "mycontroller.nevermosby.io/content-path"
+
+	// nginxRootScript points the root of the stock nginx config at
+	// $CONTENT_PATH before starting nginx.
+	nginxRootScript = `sed -i -E "s#root +/usr/share/nginx/html;#root /usr/share/nginx/html/$CONTENT_PATH;#" /etc/nginx/conf.d/default.conf && exec nginx -g 'daemon off;'`
+)
+
+// relativePathPattern restricts paths into the checkout to characters that
+// are safe in shell words and nginx directives.
+var relativePathPattern = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+
+// commitPattern matches the SHA-1 or SHA-256 of a commit.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// cleanRelativePath returns p cleaned, or an error naming field when p is
+// absolute or climbs out of the checkout with "..".
+func cleanRelativePath(field, p string) (string, error) {
+	dir := path.Clean(p)
+	if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return "", fmt.Errorf("%s %q must be relative to the checkout", field, p)
+	}
+	if !relativePathPattern.MatchString(dir) {
+		return "", fmt.Errorf("%s %q may only contain letters, digits, '.', '_', '-' and '/'", field, p)
+	}
+	return dir, nil
+}
+
+// websiteContentPath returns the cleaned spec.contentPath, "." for the
+// whole checkout.
+func websiteContentPath(website *myv1alpha1.Website) string {
+	if website.Spec.ContentPath == "" {
+		return "."
+	}
+	dir, err := cleanRelativePath("contentPath", website.Spec.ContentPath)
+	if err != nil {
+		// syncHandler refuses such a website before building pods for it
+		return "."
+	}
+	return dir
+}
+
+// validatePaths checks the paths of the website spec that point into the
+// checkout.
+func validatePaths(website *myv1alpha1.Website) error {
+	if website.Spec.ContentPath != "" {
+		if _, err := cleanRelativePath("contentPath", website.Spec.ContentPath); err != nil {
+			return err
+		}
+	}
+	if website.Spec.Build != nil {
+		if _, err := buildOutputDir(website.Spec.Build); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyContentPath makes nginx serve spec.contentPath of the checkout
+// synced by the git-sync sidecar.
+func applyContentPath(template *corev1.PodTemplateSpec, website *myv1alpha1.Website) {
+	contentPath := websiteContentPath(website)
+	if contentPath == "." {
+		return
+	}
+	// Only nginx runs with its stock configuration, other backends get a
+	// rendered one setting the root
+	if container := servingContainer(template, website); container != nil {
+		container.Command = []string{"sh", "-c", nginxRootScript}
+		container.Env = append(container.Env, corev1.EnvVar{Name: "CONTENT_PATH", Value: contentPath})
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[contentPathAnnotation] = hashJSON(contentPath)
+	}
+}
+
+// contentChecker tells whether a directory exists in a revision.
+type contentChecker interface {
+	HasDir(ctx context.Context, repo, revision, dir string) (bool, error)
+}
+
+// gitContentChecker fetches the tree of a revision without its blobs, where
+// the server allows it, and looks the directory up with ls-tree.
+type gitContentChecker struct {
+	// git is the path of the git binary, defaults to git in $PATH.
+	git string
+}
+
+func (g *gitContentChecker) HasDir(ctx context.Context, repo, revision, dir string) (bool, error) {
+	if strings.HasPrefix(repo, "-") {
+		return false, fmt.Errorf("repository %q must not start with '-'", repo)
+	}
+	if !commitPattern.MatchString(revision) {
+		return false, fmt.Errorf("revision %q is not a commit SHA", revision)
+	}
+	tmp, err := ioutil.TempDir("", "content-check")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err := g.run(ctx, tmp, "init", "--quiet"); err != nil {
+		return false, err
+	}
+	// -- keeps a repository starting with - from being read as an option
+	if _, err := g.run(ctx, tmp, "fetch", "--quiet", "--depth", "1", "--filter=blob:none", "--", repo, revision); err != nil {
+		return false, err
+	}
+	out, err := g.run(ctx, tmp, "ls-tree", "-d", "--name-only", "FETCH_HEAD", "--", dir)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == dir, nil
+}
+
+func (g *gitContentChecker) run(ctx context.Context, dir string, args ...string) (string, error) {
+	git := g.git
+	if git == "" {
+		git = "git"
+	}
+	cmd := exec.CommandContext(ctx, git, args...)
+	cmd.Dir = dir
+	// never block on a credential prompt
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// syncContentPath reports with the ContentPathFound condition whether
+// spec.contentPath exists in revision. The check fetches from the git host,
+// so it runs in the background and the condition is left as is until it
+// returned.
+func (c *Controller) syncContentPath(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, revision string) {
+	contentPath := websiteContentPath(website)
+	if contentPath == "." || websiteGitSource(website) == nil {
+		// Only git sources can be looked into before the pods fetch them
+		removeWebsiteCondition(status, myv1alpha1.WebsiteContentPathFound)
+		return
+	}
+	if revision == "" {
+		// nothing to look into until the branch is resolved
+		return
+	}
+	repo := websiteRepo(website)
+	key := "content " + repo + "#" + revision + ":" + contentPath
+	result, ok, err := c.gitTasks.Do(key, contentCheckTTL, func(ctx context.Context) (interface{}, error) {
+		return c.content.HasDir(ctx, repo, revision, contentPath)
+	}, func() { c.enqueueWebsiteAfter(website, 0) })
+	if !ok {
+		return
+	}
+	if err != nil {
+		setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteContentPathFound, corev1.ConditionUnknown, ErrCheckContentPath, err.Error()))
+		return
+	}
+	if !result.(bool) {
+		msg := fmt.Sprintf(MessageContentPathMissing, contentPath, revision)
+		if cond := getWebsiteCondition(*status, myv1alpha1.WebsiteContentPathFound); cond == nil || cond.Message != msg {
+			c.recorder.Event(website, corev1.EventTypeWarning, ContentPathMissing, msg)
+		}
+		setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteContentPathFound, corev1.ConditionFalse, ContentPathMissing, msg))
+		return
+	}
+	setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteContentPathFound, corev1.ConditionTrue, ContentPathFound, fmt.Sprintf(MessageContentPathFound, contentPath, revision)))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+func TestGitContentCheckerHasDir(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	bare, sha := newBareRepo(t, dir)
+	checker := &gitContentChecker{}
+
+	tests := []struct {
+		name     string
+		repo     string
+		revision string
+		dir      string
+		want     bool
+		wantErr  string
+	}{
+		{name: "existing directory", repo: bare, revision: sha, dir: "docs", want: true},
+		{name: "file URL", repo: "file://" + bare, revision: sha, dir: "docs", want: true},
+		{name: "missing directory", repo: bare, revision: sha, dir: "public"},
+		{name: "file is not a directory", repo: bare, revision: sha, dir: "index.html"},
+		{name: "branch instead of commit", repo: bare, revision: "master", dir: "docs", wantErr: "not a commit SHA"},
+		{name: "option revision", repo: bare, revision: "--upload-pack=true", dir: "docs", wantErr: "not a commit SHA"},
+		{name: "option repository", repo: "--upload-pack=true", revision: sha, dir: "docs", wantErr: "must not start with '-'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checker.HasDir(context.Background(), tt.repo, tt.revision, tt.dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("HasDir() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HasDir() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("HasDir() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitContentCheckerRepoIsNotAnOption(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	_, sha := newBareRepo(t, dir)
+	pwned := filepath.Join(dir, "pwned")
+	// the check in HasDir is bypassed to test the -- alone
+	if _, err := (&gitContentChecker{}).run(context.Background(), dir, "fetch", "--quiet", "--", "--upload-pack=touch "+pwned+";", sha); err == nil {
+		t.Fatal("fetch of an option succeeded")
+	}
+	if _, err := os.Stat(pwned); err == nil {
+		t.Fatal("the repository was run as --upload-pack")
+	}
+}
+
+func TestSyncDeploymentRollsContentPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		desired string
+	}{
+		{name: "set", current: "", desired: "docs"},
+		{name: "changed", current: "docs", desired: "public"},
+		{name: "unset", current: "docs", desired: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			website := &myv1alpha1.Website{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia", UID: types.UID("kubia-uid")},
+				Spec: myv1alpha1.WebsiteSpec{
+					DeploymentName: "kubia",
+					GitRepo:        "https://github.com/nevermosby/kubia-website-example.git",
+					ContentPath:    tt.current,
+					// only the stock nginx config takes the content path as a command
+					PodSecurity: myv1alpha1.PodSecurityUnrestricted,
+				},
+			}
+			current := newDeployment(website, "0123456789abcdef0123456789abcdef01234567")
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(current); err != nil {
+				t.Fatal(err)
+			}
+			client := fake.NewSimpleClientset(current)
+			c := &Controller{
+				kubeclientset:     client,
+				deploymentsLister: appslisters.NewDeploymentLister(indexer),
+				recorder:          record.NewFakeRecorder(10),
+			}
+
+			website.Spec.ContentPath = tt.desired
+			deployment, err := c.syncDeployment(website, newDeployment(website, "0123456789abcdef0123456789abcdef01234567"))
+			if err != nil {
+				t.Fatalf("syncDeployment() error = %v", err)
+			}
+			var contentPath string
+			for _, container := range deployment.Spec.Template.Spec.Containers {
+				for _, env := range container.Env {
+					if env.Name == "CONTENT_PATH" {
+						contentPath = env.Value
+					}
+				}
+			}
+			if contentPath != tt.desired {
+				t.Errorf("CONTENT_PATH = %q after sync, want %q", contentPath, tt.desired)
+			}
+			updated := false
+			for _, action := range client.Actions() {
+				updated = updated || action.GetVerb() == "update"
+			}
+			if !updated {
+				t.Errorf("the Deployment was not updated, actions: %v", client.Actions())
+			}
+		})
+	}
+}
@@ -2,24 +2,38 @@ package main
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1core "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	_ "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	appsinformers "k8s.io/client-go/informers/apps/v1"
 	// for service
 	servicesinformers "k8s.io/client-go/informers/core/v1"
+	networkingv1informers "k8s.io/client-go/informers/networking/v1"
+	networkinginformers "k8s.io/client-go/informers/networking/v1beta1"
+	policyinformers "k8s.io/client-go/informers/policy/v1beta1"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	appslisters "k8s.io/client-go/listers/apps/v1"
+	networkingv1listers "k8s.io/client-go/listers/networking/v1"
+	networkinglisters "k8s.io/client-go/listers/networking/v1beta1"
+	policylisters "k8s.io/client-go/listers/policy/v1beta1"
 
 	v1 "k8s.io/client-go/listers/core/v1"
 
@@ -44,6 +58,20 @@ import (
 
 const controllerAgentName = "my-controller"
 
+const (
+	// defaultBranch is the git branch served when Website.spec.branch is empty.
+	defaultBranch = "master"
+
+	// syncRequestAnnotation is set on a Website by the webhook receiver with
+	// the pushed commit. A value other than status.revision makes the
+	// syncHandler resolve the branch right away instead of at the next poll.
+	syncRequestAnnotation = "mycontroller.nevermosby.io/sync-request"
+
+	// revisionAnnotation on the pod template records the commit the pods
+	// serve, so every new commit is an explicit rollout of the Deployment.
+	revisionAnnotation = "mycontroller.nevermosby.io/revision"
+)
+
 const (
 	// SuccessSynced is used as part of the Event 'reason' when a Foo is synced
 	SuccessSynced = "Synced"
@@ -57,8 +85,247 @@ const (
 	// MessageResourceSynced is the message used for an Event fired when a Foo
 	// is synced successfully
 	MessageResourceSynced = "Website synced successfully"
+
+	// SyncRequested is used as part of the Event 'reason' when a push webhook
+	// triggers a sync of a Website.
+	SyncRequested = "SyncRequested"
+	// MessageSyncRequested is the message used for an Event fired when a push
+	// webhook triggers a sync of a Website.
+	MessageSyncRequested = "Push to branch %q (%s) received from %s"
+
+	// RevisionResolved is used as part of the Event 'reason' when the
+	// followed branch resolves to a new commit.
+	RevisionResolved = "RevisionResolved"
+	// ErrResolveRevision is used as part of the Event 'reason' when the
+	// followed branch cannot be resolved.
+	ErrResolveRevision = "ErrResolveRevision"
+	// MessageRevisionResolved is the message used for an Event fired when the
+	// followed branch resolves to a new commit.
+	MessageRevisionResolved = "Branch %q resolved to %s"
+
+	// RollingBack is used as part of the Event 'reason' when spec.rollbackTo
+	// pins a Website to a previous revision.
+	RollingBack = "RollingBack"
+	// RolledBack is used as part of the Event 'reason' when the pinned
+	// revision is fully rolled out.
+	RolledBack = "RolledBack"
+	// RollbackCleared is used as part of the Event 'reason' when
+	// spec.rollbackTo is cleared and the branch is followed again.
+	RollbackCleared = "RollbackCleared"
+	// ErrRollbackRevision is used as part of the Event 'reason' when
+	// spec.rollbackTo names a revision missing from the history.
+	ErrRollbackRevision = "ErrRollbackRevision"
+	// MessageRollingBack is the message of the RolledBack condition while the
+	// pinned revision rolls out.
+	MessageRollingBack = "Pinned to revision %s"
+	// MessageRollingBackFrom is the message used for an Event fired when a
+	// rollback starts.
+	MessageRollingBackFrom = "Rolling back from revision %s to %s"
+	// MessageRolledBack is the message used for an Event fired when the
+	// pinned revision is fully rolled out.
+	MessageRolledBack = "Rolled back to revision %s"
+	// MessageRollbackCleared is the message used for an Event fired when
+	// spec.rollbackTo is cleared.
+	MessageRollbackCleared = "Rollback cleared, following branch %q at %s"
+	// MessageRollbackNotFound is the message used for an Event fired when
+	// spec.rollbackTo names a revision missing from the history.
+	MessageRollbackNotFound = "Revision %q not found in status.history"
+
+	// CanaryStarted is used as part of the Event 'reason' when a canary
+	// release of a new revision starts.
+	CanaryStarted = "CanaryStarted"
+	// CanaryStep is used as part of the Event 'reason' when the canary is
+	// ready at a step.
+	CanaryStep = "CanaryStep"
+	// CanaryPromoting is used as part of the Event 'reason' when all canary
+	// steps passed and the stable pods roll out the canary revision.
+	CanaryPromoting = "CanaryPromoting"
+	// CanaryPromoted is used as part of the Event 'reason' when the canary
+	// revision became the stable one.
+	CanaryPromoted = "CanaryPromoted"
+	// CanaryAborted is used as part of the Event 'reason' when a canary
+	// release is aborted.
+	CanaryAborted = "CanaryAborted"
+	// MessageCanaryStarted is the message used for an Event fired when a
+	// canary release starts.
+	MessageCanaryStarted = "Canary release of revision %s started, stable revision is %s"
+	// MessageCanaryStep is the message used for an Event fired when the
+	// canary is ready at a step.
+	MessageCanaryStep = "Canary step %d/%d ready, serving %d%% of the traffic"
+	// MessageCanaryPromoting is the message used for an Event fired when the
+	// canary is being promoted.
+	MessageCanaryPromoting = "Promoting canary revision %s"
+	// MessageCanaryPromoted is the message used for an Event fired when the
+	// canary revision became the stable one.
+	MessageCanaryPromoted = "Canary revision %s promoted"
+	// MessageCanaryAborted is the message used for an Event fired when a
+	// canary release is aborted.
+	MessageCanaryAborted = "Canary release of revision %s aborted: %s"
+
+	// ErrInvalidSource is used as part of the Event 'reason' when the
+	// website has no source or one that cannot be fetched.
+	ErrInvalidSource = "ErrInvalidSource"
+	// ErrInvalidPath is used as part of the Event 'reason' when a path of the
+	// website spec points outside of the checkout.
+	ErrInvalidPath = "ErrInvalidPath"
+	// ErrInvalidServer is used as part of the Event 'reason' when
+	// spec.server or spec.auth cannot be rendered into a server
+	// configuration.
+	ErrInvalidServer = "ErrInvalidServer"
+	// ErrInvalidScheduling is used as part of the Event 'reason' when the
+	// scheduling fields of the website spec are invalid.
+	ErrInvalidScheduling = "ErrInvalidScheduling"
+	// ErrInvalidPodSecurity is used as part of the Event 'reason' when
+	// spec.podSecurity is not a known mode.
+	ErrInvalidPodSecurity = "ErrInvalidPodSecurity"
+	// ErrInvalidNetworkPolicy is used as part of the Event 'reason' when
+	// spec.networkPolicy holds a malformed CIDR or selector.
+	ErrInvalidNetworkPolicy = "ErrInvalidNetworkPolicy"
+	// ErrInvalidAvailability is used as part of the Event 'reason' when
+	// spec.availability or spec.deployment is invalid.
+	ErrInvalidAvailability = "ErrInvalidAvailability"
+	// ErrInvalidOverrides is used as part of the Event 'reason' when
+	// spec.podTemplateOverrides cannot be merged into the pod template.
+	ErrInvalidOverrides = "ErrInvalidOverrides"
+	// ErrInvalidServiceType is used as part of the Event 'reason' when
+	// spec.serviceType is not a supported Service type.
+	ErrInvalidServiceType = "ErrInvalidServiceType"
+	// ErrInvalidStrategy is used as part of the Event 'reason' when
+	// spec.strategy cannot be rolled out.
+	ErrInvalidStrategy = "ErrInvalidStrategy"
+	// ErrPolicyViolation is used as part of the Event 'reason' when a Website
+	// violates a WebsitePolicy or ClusterWebsitePolicy.
+	ErrPolicyViolation = "ErrPolicyViolation"
+	// PoliciesSatisfied is the reason of the PolicyViolated condition when a
+	// Website satisfies the policies of its namespace.
+	PoliciesSatisfied = "PoliciesSatisfied"
+	// ErrConflict is used as part of the Event 'reason' when a Website shares
+	// its deployment, service or host with an older one.
+	ErrConflict = "ErrConflict"
+	// TemplateResolved is the reason of the TemplateResolved condition when
+	// the WebsiteTemplate of a Website is resolved.
+	TemplateResolved = "TemplateResolved"
+	// ErrResolveTemplate is used as part of the Event 'reason' when the
+	// WebsiteTemplate of a Website is missing or cannot be merged.
+	ErrResolveTemplate = "ErrResolveTemplate"
+	// MessageTemplateResolved is the message of the TemplateResolved
+	// condition.
+	MessageTemplateResolved = "Inheriting from WebsiteTemplate %s at generation %d"
+	// ErrInvalidTargetNamespace is used as part of the Event 'reason' when a
+	// ClusterWebsite has no target namespace.
+	ErrInvalidTargetNamespace = "ErrInvalidTargetNamespace"
+	// MessageMissingTargetNamespace is the message used for an Event fired
+	// when a ClusterWebsite has no target namespace.
+	MessageMissingTargetNamespace = "spec.targetNamespace must be specified"
+	// PodTemplateOverridden is used as part of the Event 'reason' when the
+	// pod template of a Deployment is overridden by a new patch.
+	PodTemplateOverridden = "PodTemplateOverridden"
+	// MessagePodTemplateOverridden is the message used for an Event fired
+	// when the pod template of a Deployment is overridden by a new patch.
+	MessagePodTemplateOverridden = "Pod template of Deployment %q overridden with %s"
+	// ResourceAdopted is used as part of the Event 'reason' when a Website
+	// with spec.adoptExisting takes over an existing Deployment or Service.
+	ResourceAdopted = "ResourceAdopted"
+	// MessageResourceAdopted is the message used for an Event fired when a
+	// Website adopts a resource, listing what changed.
+	MessageResourceAdopted = "Adopted %s %q: %s"
+	// ErrAdoptResource is used as part of the Event 'reason' when an existing
+	// resource cannot be adopted.
+	ErrAdoptResource = "ErrAdoptResource"
+	// MessageSelectorMismatch is the message used for an Event fired when an
+	// existing Deployment cannot be adopted for its immutable selector.
+	MessageSelectorMismatch = "Deployment %q cannot be adopted, its selector %s differs from %s and cannot be changed"
+	// ContentPathFound is used as part of the ContentPathFound condition
+	// 'reason' when spec.contentPath exists in the revision.
+	ContentPathFound = "ContentPathFound"
+	// ContentPathMissing is used as part of the Event 'reason' when
+	// spec.contentPath does not exist in the revision.
+	ContentPathMissing = "ContentPathMissing"
+	// ErrCheckContentPath is used as part of the ContentPathFound condition
+	// 'reason' when the revision could not be fetched to look the path up.
+	ErrCheckContentPath = "ErrCheckContentPath"
+	// MessageContentPathFound is the message of the ContentPathFound
+	// condition when spec.contentPath exists in the revision.
+	MessageContentPathFound = "Path %q found in revision %s"
+	// MessageContentPathMissing is the message used for an Event fired when
+	// spec.contentPath does not exist in the revision.
+	MessageContentPathMissing = "Path %q not found in revision %s"
+
+	// BuildSucceeded is used as part of the Event 'reason' when the pods of a
+	// revision built it.
+	BuildSucceeded = "BuildSucceeded"
+	// BuildFailed is used as part of the Event 'reason' when the build of a
+	// revision fails.
+	BuildFailed = "BuildFailed"
+	// MessageBuildSucceeded is the message used for an Event fired when the
+	// pods of a revision built it.
+	MessageBuildSucceeded = "Revision %s built"
+	// MessageBuildFailed is the message used for an Event fired when the
+	// build of a revision fails.
+	MessageBuildFailed = "Build of revision %s failed: %s"
+
+	// ErrSyncSource is used as part of the Event 'reason' when a source of
+	// spec.sources fails to sync.
+	ErrSyncSource = "ErrSyncSource"
+	// MessageSyncSourceFailed is the message used for an Event fired when a
+	// source of spec.sources fails to sync.
+	MessageSyncSourceFailed = "Source %s failed to sync: %s"
+
+	// ErrAuthSecret is used as part of the Event 'reason' when a Secret
+	// mounted by the website pods does not exist.
+	ErrAuthSecret = "ErrAuthSecret"
+	// MessageAuthSecretNotFound is the message used for an Event fired when
+	// a Secret mounted by the website pods does not exist.
+	MessageAuthSecretNotFound = "Secret %q not found"
+
+	// PodEvicted is used as part of the Event 'reason' when a website pod is
+	// evicted, e.g. for outgrowing the content size limit.
+	PodEvicted = "PodEvicted"
+	// ContainerOOMKilled is used as part of the Event 'reason' when a
+	// container of a website pod is killed for exceeding its memory limit.
+	ContainerOOMKilled = "ContainerOOMKilled"
+	// MessagePodEvicted is the message used for an Event fired when a
+	// website pod is evicted.
+	MessagePodEvicted = "Pod %s evicted: %s"
+	// MessageContainerOOMKilled is the message used for an Event fired when
+	// a container of a website pod is killed for exceeding its memory limit.
+	MessageContainerOOMKilled = "Container %s of pod %s was OOM killed"
+
+	// BlueGreenSwitched is used as part of the Event 'reason' when the
+	// service of a blue/green website switches color.
+	BlueGreenSwitched = "BlueGreenSwitched"
+	// MessageBlueGreenSwitched is the message used for an Event fired when
+	// the service of a blue/green website switches color.
+	MessageBlueGreenSwitched = "Switched to %s serving revision %s"
+
+	// PreviewExpired is used as part of the Event 'reason' when the TTL of a
+	// WebsitePreview elapsed and it is deleted.
+	PreviewExpired = "PreviewExpired"
+	// ErrPreviewWebsite is used as part of the Event 'reason' when the parent
+	// website of a WebsitePreview does not exist.
+	ErrPreviewWebsite = "ErrPreviewWebsite"
+	// ErrInvalidPreview is used as part of the Event 'reason' when a
+	// WebsitePreview is invalid, violates a policy or takes a name held by
+	// another website.
+	ErrInvalidPreview = "ErrInvalidPreview"
+	// MessagePreviewExpired is the message used for an Event fired when a
+	// WebsitePreview expired.
+	MessagePreviewExpired = "Preview TTL elapsed, deleting the preview"
+	// MessagePreviewWebsiteNotFound is the message used for an Event fired
+	// when the parent website of a WebsitePreview does not exist.
+	MessagePreviewWebsiteNotFound = "Website %q not found"
+	// MessagePreviewWebsiteNotGit is the message used for an Event fired
+	// when the parent website of a preview is not served from git.
+	MessagePreviewWebsiteNotGit = "Website %q is not served from a git repository"
 )
 
+// ownerObject is a resource controlling the objects created for it, a
+// Website or a WebsitePreview.
+type ownerObject interface {
+	metav1.Object
+	runtime.Object
+}
+
 // Controller is the controller implementation for website resources
 type Controller struct {
 	// kubeclientset is a standard kubernetes clientset
@@ -68,13 +335,55 @@ type Controller struct {
 
 	// service list
 	servicesLister v1.ServiceLister
+	// ingress list
+	ingressesLister networkinglisters.IngressLister
 
 	deploymentsLister appslisters.DeploymentLister
 	deploymentsSynced cache.InformerSynced
+	ingressesSynced   cache.InformerSynced
+	// networkpolicy list, for spec.networkPolicy
+	networkPoliciesLister networkingv1listers.NetworkPolicyLister
+	networkPoliciesSynced cache.InformerSynced
+	// poddisruptionbudget list, for spec.availability
+	pdbsLister policylisters.PodDisruptionBudgetLister
+	pdbsSynced cache.InformerSynced
+	// namespace list, for the namespaceSelector of ClusterWebsitePolicies
+	namespacesLister v1.NamespaceLister
+	namespacesSynced cache.InformerSynced
+	// pod list, used to report builds
+	podsLister v1.PodLister
+	podsSynced cache.InformerSynced
+	// configmap list, for the server configuration
+	configMapsLister v1.ConfigMapLister
+	configMapsSynced cache.InformerSynced
+	// secret list, for the htpasswd files of basic auth
+	secretsLister v1.SecretLister
+	secretsSynced cache.InformerSynced
 	// websitesLister        listers.WebsiteLister
 	websitesLister listers.WebsiteLister
 	// websitesSynced        cache.InformerSynced
 	websitesSynced cache.InformerSynced
+	// websitesIndexer looks up websites by gitRepoIndex and the uniqueness
+	// indexes
+	websitesIndexer cache.Indexer
+	// ClusterWebsites are synced as Websites in their target namespace
+	clusterWebsitesLister listers.ClusterWebsiteLister
+	clusterWebsitesSynced cache.InformerSynced
+	// clusterWebsitesIndexer looks up ClusterWebsites by gitRepoIndex,
+	// authSecretIndex, templateIndex and the uniqueness indexes
+	clusterWebsitesIndexer cache.Indexer
+
+	previewsLister listers.WebsitePreviewLister
+	previewsSynced cache.InformerSynced
+	// previewsIndexer looks up previews by previewWebsiteIndex
+	previewsIndexer cache.Indexer
+
+	policiesLister        listers.WebsitePolicyLister
+	policiesSynced        cache.InformerSynced
+	clusterPoliciesLister listers.ClusterWebsitePolicyLister
+	clusterPoliciesSynced cache.InformerSynced
+	templatesLister       listers.WebsiteTemplateLister
+	templatesSynced       cache.InformerSynced
 
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
@@ -82,9 +391,26 @@ type Controller struct {
 	// time, and makes it easy to ensure we are never processing the same item
 	// simultaneously in two different workers.
 	workqueue workqueue.RateLimitingInterface
+	// previewsWorkqueue queues WebsitePreviews the same way.
+	previewsWorkqueue workqueue.RateLimitingInterface
 	// recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
 	recorder record.EventRecorder
+
+	// resolver resolves the followed branch of a website to a commit
+	resolver revisionResolver
+	// content looks spec.contentPath up in a revision
+	content contentChecker
+	// gitTasks runs the calls of resolver and content off the workers
+	gitTasks *gitTasks
+	// gitPollInterval is how often the followed branch is resolved
+	gitPollInterval time.Duration
+
+	// syncedMu guards synced
+	syncedMu sync.Mutex
+	// synced holds by key a hash of the spec and revision each website was
+	// last synced to, so the poll resyncs changing neither fire no event
+	synced map[string]string
 }
 
 // NewController returns a new sample controller
@@ -93,7 +419,20 @@ func NewController(
 	sampleclientset clientset.Interface,
 	deploymentInformer appsinformers.DeploymentInformer,
 	serviceInformer servicesinformers.ServiceInformer,
-	websiteInformer informers.WebsiteInformer) *Controller {
+	podInformer servicesinformers.PodInformer,
+	configMapInformer servicesinformers.ConfigMapInformer,
+	secretInformer servicesinformers.SecretInformer,
+	namespaceInformer servicesinformers.NamespaceInformer,
+	ingressInformer networkinginformers.IngressInformer,
+	networkPolicyInformer networkingv1informers.NetworkPolicyInformer,
+	pdbInformer policyinformers.PodDisruptionBudgetInformer,
+	websiteInformer informers.WebsiteInformer,
+	clusterWebsiteInformer informers.ClusterWebsiteInformer,
+	previewInformer informers.WebsitePreviewInformer,
+	policyInformer informers.WebsitePolicyInformer,
+	clusterPolicyInformer informers.ClusterWebsitePolicyInformer,
+	templateInformer informers.WebsiteTemplateInformer,
+	gitPollInterval time.Duration) *Controller {
 
 	// Create event broadcaster
 	// Add my-controller types to the default Kubernetes Scheme so Events can be
@@ -107,17 +446,75 @@ func NewController(
 
 	// 初始化控制器
 	controller := &Controller{
-		kubeclientset:     kubeclientset,
-		sampleclientset:   sampleclientset,
-		deploymentsLister: deploymentInformer.Lister(),
-		servicesLister:    serviceInformer.Lister(),
-		deploymentsSynced: deploymentInformer.Informer().HasSynced,
-		websitesLister:    websiteInformer.Lister(),
-		websitesSynced:    websiteInformer.Informer().HasSynced,
-		workqueue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Websites"),
-		recorder:          recorder,
+		kubeclientset:          kubeclientset,
+		sampleclientset:        sampleclientset,
+		deploymentsLister:      deploymentInformer.Lister(),
+		servicesLister:         serviceInformer.Lister(),
+		podsLister:             podInformer.Lister(),
+		podsSynced:             podInformer.Informer().HasSynced,
+		configMapsLister:       configMapInformer.Lister(),
+		configMapsSynced:       configMapInformer.Informer().HasSynced,
+		secretsLister:          secretInformer.Lister(),
+		secretsSynced:          secretInformer.Informer().HasSynced,
+		namespacesLister:       namespaceInformer.Lister(),
+		namespacesSynced:       namespaceInformer.Informer().HasSynced,
+		ingressesLister:        ingressInformer.Lister(),
+		deploymentsSynced:      deploymentInformer.Informer().HasSynced,
+		ingressesSynced:        ingressInformer.Informer().HasSynced,
+		networkPoliciesLister:  networkPolicyInformer.Lister(),
+		networkPoliciesSynced:  networkPolicyInformer.Informer().HasSynced,
+		pdbsLister:             pdbInformer.Lister(),
+		pdbsSynced:             pdbInformer.Informer().HasSynced,
+		websitesLister:         websiteInformer.Lister(),
+		websitesSynced:         websiteInformer.Informer().HasSynced,
+		websitesIndexer:        websiteInformer.Informer().GetIndexer(),
+		clusterWebsitesLister:  clusterWebsiteInformer.Lister(),
+		clusterWebsitesSynced:  clusterWebsiteInformer.Informer().HasSynced,
+		clusterWebsitesIndexer: clusterWebsiteInformer.Informer().GetIndexer(),
+		previewsLister:         previewInformer.Lister(),
+		previewsSynced:         previewInformer.Informer().HasSynced,
+		previewsIndexer:        previewInformer.Informer().GetIndexer(),
+		policiesLister:         policyInformer.Lister(),
+		policiesSynced:         policyInformer.Informer().HasSynced,
+		clusterPoliciesLister:  clusterPolicyInformer.Lister(),
+		clusterPoliciesSynced:  clusterPolicyInformer.Informer().HasSynced,
+		templatesLister:        templateInformer.Lister(),
+		templatesSynced:        templateInformer.Informer().HasSynced,
+		workqueue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Websites"),
+		previewsWorkqueue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "WebsitePreviews"),
+		recorder:               websiteEventRecorder{recorder},
+		resolver:               &lsRemoteResolver{},
+		content:                &gitContentChecker{},
+		gitTasks:               newGitTasks(),
+		synced:                 map[string]string{},
+		gitPollInterval:        gitPollInterval,
 	}
 
+	// Index websites by repository and branch so push webhooks can find them,
+	// and by the names they must not share
+	utilruntime.Must(websiteInformer.Informer().AddIndexers(cache.Indexers{
+		gitRepoIndex:        indexWebsiteByGitRepo,
+		authSecretIndex:     indexWebsiteByAuthSecret,
+		templateIndex:       indexWebsiteByTemplate,
+		websiteNameIndex:    uniqueIndexFunc(websiteNameIndex),
+		deploymentNameIndex: uniqueIndexFunc(deploymentNameIndex),
+		serviceNameIndex:    uniqueIndexFunc(serviceNameIndex),
+		hostIndex:           uniqueIndexFunc(hostIndex),
+	}))
+	utilruntime.Must(clusterWebsiteInformer.Informer().AddIndexers(cache.Indexers{
+		gitRepoIndex:        indexWebsiteByGitRepo,
+		authSecretIndex:     indexWebsiteByAuthSecret,
+		templateIndex:       indexWebsiteByTemplate,
+		websiteNameIndex:    uniqueIndexFunc(websiteNameIndex),
+		deploymentNameIndex: uniqueIndexFunc(deploymentNameIndex),
+		serviceNameIndex:    uniqueIndexFunc(serviceNameIndex),
+		hostIndex:           uniqueIndexFunc(hostIndex),
+	}))
+	// Index previews by their parent website so they follow its changes
+	utilruntime.Must(previewInformer.Informer().AddIndexers(cache.Indexers{
+		previewWebsiteIndex: indexPreviewByWebsite,
+	}))
+
 	klog.Info("Setting up event handlers")
 	// important
 	// Set up an event handler for when website resources change
@@ -127,6 +524,96 @@ func NewController(
 			controller.enqueueWebsite(new)
 		},
 	})
+	clusterWebsiteInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueWebsite,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueWebsite(new)
+		},
+	})
+	// Previews are synced when they change and when their parent website does
+	previewInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueuePreview,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueuePreview(new)
+		},
+	})
+	// A preview in conflict with another is synced again once the other
+	// is deleted or moves to another host
+	previewInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			oldPreview, newPreview := old.(*myv1alpha1.WebsitePreview), new.(*myv1alpha1.WebsitePreview)
+			if oldPreview.Spec.Host != newPreview.Spec.Host || oldPreview.Spec.Branch != newPreview.Spec.Branch {
+				controller.enqueueAllPreviews()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			controller.enqueueAllPreviews()
+		},
+	})
+	websiteInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueWebsitePreviews,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueWebsitePreviews(new)
+		},
+		DeleteFunc: controller.enqueueWebsitePreviews,
+	})
+	// A website in conflict with another is synced again once the other
+	// releases the name, so are the previews, which give way to websites
+	conflictHandler := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			if uniqueKeysChanged(old, new) {
+				controller.enqueueConflictingWebsites(old)
+				controller.enqueueAllPreviews()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			controller.enqueueConflictingWebsites(obj)
+			controller.enqueueAllPreviews()
+		},
+	}
+	websiteInformer.Informer().AddEventHandler(conflictHandler)
+	clusterWebsiteInformer.Informer().AddEventHandler(conflictHandler)
+	// Policies apply to all websites of their namespaces, which are synced
+	// again when they change. So are the websites of a namespace whose
+	// labels change, ClusterWebsitePolicies may select it now.
+	policyHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueNamespaceWebsites,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueNamespaceWebsites(new)
+		},
+		DeleteFunc: controller.enqueueNamespaceWebsites,
+	}
+	policyInformer.Informer().AddEventHandler(policyHandler)
+	clusterPolicyInformer.Informer().AddEventHandler(policyHandler)
+	namespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			newNS := new.(*corev1.Namespace)
+			oldNS := old.(*corev1.Namespace)
+			if equality.Semantic.DeepEqual(newNS.Labels, oldNS.Labels) {
+				return
+			}
+			controller.enqueueNamespaceWebsites(new)
+		},
+	})
+	// Websites inheriting from a template, found through templateIndex, are
+	// synced again when it changes
+	templateInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueTemplateWebsites,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueTemplateWebsites(new)
+		},
+		DeleteFunc: controller.enqueueTemplateWebsites,
+	})
+	// The replicas of a website count against the namespace replicas of the
+	// websites created after it
+	websiteInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			if websiteReplicas(old.(*myv1alpha1.Website)) != websiteReplicas(new.(*myv1alpha1.Website)) {
+				controller.enqueueNamespaceWebsites(new)
+			}
+		},
+		DeleteFunc: controller.enqueueNamespaceWebsites,
+	})
 	// Set up an event handler for when Deployment resources change. This
 	// handler will lookup the owner of the given Deployment, and if it is
 	// owned by a website resource will enqueue that website resource for
@@ -147,6 +634,89 @@ func NewController(
 		},
 		DeleteFunc: controller.handleObject,
 	})
+	// Ingresses are handled the same way, so changes to an owned Ingress are
+	// reverted to the spec of the website.
+	ingressInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleObject,
+		UpdateFunc: func(old, new interface{}) {
+			newIng := new.(*networkingv1beta1.Ingress)
+			oldIng := old.(*networkingv1beta1.Ingress)
+			if newIng.ResourceVersion == oldIng.ResourceVersion {
+				return
+			}
+			controller.handleObject(new)
+		},
+		DeleteFunc: controller.handleObject,
+	})
+
+	// NetworkPolicies too, so a loosened policy is tightened again.
+	networkPolicyInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleObject,
+		UpdateFunc: func(old, new interface{}) {
+			newPolicy := new.(*networkingv1.NetworkPolicy)
+			oldPolicy := old.(*networkingv1.NetworkPolicy)
+			if newPolicy.ResourceVersion == oldPolicy.ResourceVersion {
+				return
+			}
+			controller.handleObject(new)
+		},
+		DeleteFunc: controller.handleObject,
+	})
+
+	// PodDisruptionBudgets as well.
+	pdbInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleObject,
+		UpdateFunc: func(old, new interface{}) {
+			newPDB := new.(*policyv1beta1.PodDisruptionBudget)
+			oldPDB := old.(*policyv1beta1.PodDisruptionBudget)
+			if newPDB.ResourceVersion == oldPDB.ResourceVersion {
+				return
+			}
+			controller.handleObject(new)
+		},
+		DeleteFunc: controller.handleObject,
+	})
+
+	// ConfigMaps are handled like Deployments, so edits of a rendered
+	// configuration are reverted.
+	configMapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleObject,
+		UpdateFunc: func(old, new interface{}) {
+			newCM := new.(*corev1.ConfigMap)
+			oldCM := old.(*corev1.ConfigMap)
+			if newCM.ResourceVersion == oldCM.ResourceVersion {
+				return
+			}
+			controller.handleObject(new)
+		},
+		DeleteFunc: controller.handleObject,
+	})
+	// Secrets are not owned by websites, the ones referenced by spec.auth
+	// are found through authSecretIndex
+	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleSecret,
+		UpdateFunc: func(old, new interface{}) {
+			newSecret := new.(*corev1.Secret)
+			oldSecret := old.(*corev1.Secret)
+			if newSecret.ResourceVersion == oldSecret.ResourceVersion {
+				return
+			}
+			controller.handleSecret(new)
+		},
+		DeleteFunc: controller.handleSecret,
+	})
+	// Pods running a build report its outcome to their website
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handlePod,
+		UpdateFunc: func(old, new interface{}) {
+			newPod := new.(*corev1.Pod)
+			oldPod := old.(*corev1.Pod)
+			if newPod.ResourceVersion == oldPod.ResourceVersion {
+				return
+			}
+			controller.handlePod(new)
+		},
+	})
 
 	return controller
 }
@@ -159,6 +729,7 @@ func NewController(
 func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	defer utilruntime.HandleCrash()
 	defer c.workqueue.ShutDown()
+	defer c.previewsWorkqueue.ShutDown()
 
 	// Start the informer factories to begin populating the informer caches
 	klog.Info("Starting website controller")
@@ -166,7 +737,7 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	// 在worker运行之前，必须要等待状态的同步完成
 	// Wait for the caches to be synced before starting workers
 	klog.Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.deploymentsSynced, c.websitesSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.deploymentsSynced, c.ingressesSynced, c.networkPoliciesSynced, c.pdbsSynced, c.podsSynced, c.configMapsSynced, c.secretsSynced, c.namespacesSynced, c.websitesSynced, c.clusterWebsitesSynced, c.previewsSynced, c.policiesSynced, c.clusterPoliciesSynced, c.templatesSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
@@ -176,6 +747,7 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	// Launch n workers to process website resources
 	for i := 0; i < threadiness; i++ {
 		go wait.Until(c.runWorker, time.Second, stopCh)
+		go wait.Until(c.runPreviewWorker, time.Second, stopCh)
 	}
 
 	klog.Info("Started workers")
@@ -189,14 +761,20 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 // processNextWorkItem function in order to read and process a message on the
 // workqueue.
 func (c *Controller) runWorker() {
-	for c.processNextWorkItem() {
+	for c.processNextWorkItem(c.workqueue, c.syncHandler) {
+	}
+}
+
+// runPreviewWorker does the same for the WebsitePreview workqueue.
+func (c *Controller) runPreviewWorker() {
+	for c.processNextWorkItem(c.previewsWorkqueue, c.syncPreview) {
 	}
 }
 
 // processNextWorkItem will read a single work item off the workqueue and
 // attempt to process it, by calling the syncHandler.
-func (c *Controller) processNextWorkItem() bool {
-	obj, shutdown := c.workqueue.Get()
+func (c *Controller) processNextWorkItem(queue workqueue.RateLimitingInterface, syncHandler func(string) error) bool {
+	obj, shutdown := queue.Get()
 
 	if shutdown {
 		return false
@@ -210,7 +788,7 @@ func (c *Controller) processNextWorkItem() bool {
 		// not call Forget if a transient error occurs, instead the item is
 		// put back on the workqueue and attempted again after a back-off
 		// period.
-		defer c.workqueue.Done(obj)
+		defer queue.Done(obj)
 		var key string
 		var ok bool
 		// We expect strings to come off the workqueue. These are of the
@@ -222,20 +800,20 @@ func (c *Controller) processNextWorkItem() bool {
 			// As the item in the workqueue is actually invalid, we call
 			// Forget here else we'd go into a loop of attempting to
 			// process a work item that is invalid.
-			c.workqueue.Forget(obj)
+			queue.Forget(obj)
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// Foo resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		if err := syncHandler(key); err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
-			c.workqueue.AddRateLimited(key)
+			queue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
 		// Finally, if no error occurs we Forget this item so it does not
 		// get queued again until another change happens.
-		c.workqueue.Forget(obj)
+		queue.Forget(obj)
 		klog.Infof("Successfully synced '%s'", key)
 		return nil
 	}(obj)
@@ -259,19 +837,49 @@ func (c *Controller) syncHandler(key string) error {
 		return nil
 	}
 
-	// Get the Website resource with this namespace/name
-	website, err := c.websitesLister.Websites(namespace).Get(name)
+	// Get the Website resource with this namespace/name, or the
+	// ClusterWebsite with this name
+	var website *myv1alpha1.Website
+	if namespace == "" {
+		var clusterWebsite *myv1alpha1.ClusterWebsite
+		if clusterWebsite, err = c.clusterWebsitesLister.Get(name); err == nil {
+			website = clusterWebsiteView(clusterWebsite)
+		}
+	} else {
+		website, err = c.websitesLister.Websites(namespace).Get(name)
+	}
 	if err != nil {
 		// The Website resource may no longer exist, in which case we stop
 		// processing.
 		if errors.IsNotFound(err) {
 			utilruntime.HandleError(fmt.Errorf("website '%s' in work queue no longer exists", key))
+			c.forgetSynced(key)
 			return nil
 		}
 
 		return err
 	}
 
+	// Inherit the defaults of the WebsiteTemplate. The stored website is
+	// kept to update the status, so the effective spec is not written back.
+	stored := website
+	website, template, err := c.resolveWebsite(stored)
+	status := stored.Status.DeepCopy()
+	syncTemplateCondition(stored, status, template, err)
+	if err != nil {
+		// Absorbed, the website is synced again once the template changes
+		c.recorder.Event(stored, corev1.EventTypeWarning, ErrResolveTemplate, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return c.updateUnsyncedStatus(stored, status)
+	}
+
+	if website.Namespace == "" {
+		// A ClusterWebsite without a target namespace, absorbed like a
+		// missing deployment name
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidTargetNamespace, MessageMissingTargetNamespace)
+		utilruntime.HandleError(fmt.Errorf("%s: %s", key, MessageMissingTargetNamespace))
+		return nil
+	}
 	deploymentName := website.Spec.DeploymentName
 	if deploymentName == "" {
 		// We choose to absorb the error here as the worker would requeue the
@@ -280,86 +888,393 @@ func (c *Controller) syncHandler(key string) error {
 		utilruntime.HandleError(fmt.Errorf("%s: deployment name must be specified", key))
 		return nil
 	}
+	if err := validateSource(website); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidSource, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	if err := validatePaths(website); err != nil {
+		// Absorbed like a missing deployment name, the spec has to change
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidPath, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	if err := validateServer(website.Spec.Server); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidServer, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	if err := validateAuth(website.Spec.Auth); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidServer, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	if err := validateScheduling(website); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidScheduling, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	if err := validatePodSecurity(website); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidPodSecurity, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	if err := validateNetworkPolicy(website); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidNetworkPolicy, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	if err := validateAvailability(website); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidAvailability, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	if err := validatePodTemplateOverrides(website); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidOverrides, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
 
-	// use deploynment name for service name
-	serviceName := fmt.Sprintf("%s-%s", deploymentName, "npsvc")
-	// just for test, show all service
-	// allService, err := c.servicesLister.List(labels.Everything())
-	// klog.Infof("all service list: %v", allService)
+	if err := validateServiceType(website); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidServiceType, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	if err := validateStrategy(website); err != nil {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrInvalidStrategy, err.Error())
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+
+	// A website sharing its deployment, service or host with an older one is
+	// not synced, instead of fighting over them
+	conflicts, err := c.syncConflicts(website, status)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		utilruntime.HandleError(fmt.Errorf("%s: conflicts: %s", key, strings.Join(conflicts, "; ")))
+		return c.updateUnsyncedStatus(stored, status)
+	}
+
+	// A website violating a policy is not synced, what already runs is left
+	// as it is until the website or the policies change
+	violations, err := c.syncPolicies(website, status)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		utilruntime.HandleError(fmt.Errorf("%s: violates policies: %s", key, strings.Join(violations, "; ")))
+		return c.updateUnsyncedStatus(stored, status)
+	}
+
+	// Render the server configuration the pods mount
+	if err := c.syncServerConfig(website); err != nil {
+		return err
+	}
+
+	// Resolve the followed branch so the pods are pinned to a commit, unless
+	// the website is rolled back to a previous one
+	c.syncRevision(website, status)
+	revision, triggeredBy := c.targetRevision(website, status)
+	if err := c.syncSources(website, status, revision); err != nil {
+		return err
+	}
+	// The Deployments pin the other git sources to the revisions resolved
+	// above
+	site := website.DeepCopy()
+	site.Status.Sources = status.Sources
 
-	webSiteService, err := c.servicesLister.Services(website.Namespace).Get(serviceName)
+	// Roll the pods out to the revision. The strategy tells which Deployment
+	// serves the website, which revision it serves and which pods the service
+	// should select.
+	var deployment *appsv1.Deployment
+	var servedRevision string
+	var selector map[string]string
+	if blueGreenStrategy(website) != nil {
+		deployment, servedRevision, selector, err = c.syncBlueGreen(site, status, revision)
+	} else {
+		deployment, servedRevision, selector, err = c.syncStable(site, status, revision, triggeredBy)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Create the service fronting the website pods
+	service := newService(website)
+	if selector != nil {
+		service.Spec.Selector = selector
+	}
+	if _, err := c.syncService(website, service); err != nil {
+		return err
+	}
+
+	// Expose the service on the website host
+	if err := c.syncIngress(website); err != nil {
+		return err
+	}
+	// Restrict the traffic of the pods to what the website needs
+	if err := c.syncNetworkPolicy(website); err != nil {
+		return err
+	}
+	// Keep node drains from taking too many pods down at once
+	if err := c.syncPodDisruptionBudget(website); err != nil {
+		return err
+	}
+
+	c.recordRevision(website, status, servedRevision, triggeredBy)
+	c.syncRollbackStatus(website, status, deployment)
+	c.syncContentPath(website, status, revision)
+	if err := c.syncBuild(website, status, revision); err != nil {
+		return err
+	}
+	if err := c.syncPodHealth(website, status); err != nil {
+		return err
+	}
+
+	// Finally, we update the status block of the website resource to reflect the
+	// current state of the world
+	err = c.updateWebsiteStatus(stored, status, deployment)
+	if err != nil {
+		return err
+	}
+
+	// Poll the followed branch again once the interval elapsed
+	c.workqueue.AddAfter(key, c.gitPollInterval)
+
+	if c.syncedChanged(key, website, servedRevision) {
+		c.recorder.Event(website, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+	}
+	return nil
+}
+
+// syncedChanged records that the website of key was synced to its spec and
+// revision, and returns whether they differ from the ones of its last sync.
+func (c *Controller) syncedChanged(key string, website *myv1alpha1.Website, revision string) bool {
+	hash := hashJSON(struct {
+		Spec     myv1alpha1.WebsiteSpec
+		Revision string
+	}{website.Spec, revision})
+	c.syncedMu.Lock()
+	defer c.syncedMu.Unlock()
+	if c.synced == nil {
+		c.synced = map[string]string{}
+	}
+	if c.synced[key] == hash {
+		return false
+	}
+	c.synced[key] = hash
+	return true
+}
+
+// forgetSynced drops what the website of key was last synced to, once it is
+// deleted.
+func (c *Controller) forgetSynced(key string) {
+	c.syncedMu.Lock()
+	defer c.syncedMu.Unlock()
+	delete(c.synced, key)
+}
+
+// syncStable rolls the website Deployment named in the spec out to revision,
+// through a canary release when the website asks for one.
+func (c *Controller) syncStable(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, revision, triggeredBy string) (*appsv1.Deployment, string, map[string]string, error) {
+	// Get the deployment with the name specified in Website.spec
+	deployment, err := c.deploymentsLister.Deployments(website.Namespace).Get(website.Spec.DeploymentName)
 	if errors.IsNotFound(err) {
-		klog.Info("not found target website service, about to create")
-		targetService, err := c.kubeclientset.CoreV1().Services(website.Namespace).Create(newService(website))
+		deployment, err = nil, nil
+	}
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// A canary release decides when the stable pods move to the new revision
+	stableRevision, stableReplicas, err := c.syncCanary(website, status, revision, triggeredBy, deployment)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	desired := newDeployment(website, stableRevision)
+	desired.Spec.Replicas = stableReplicas
+
+	deployment, err = c.syncDeployment(website, desired)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// Keep serving a previous blue/green color until the Deployment took over
+	selector, err := c.finishBlueGreen(website, status, deployment)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return deployment, stableRevision, selector, nil
+}
 
+// deleteDeployment deletes the named Deployment if the owner controls it.
+func (c *Controller) deleteDeployment(owner ownerObject, name string) error {
+	deployment, err := c.deploymentsLister.Deployments(owner.GetNamespace()).Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(deployment, owner) {
+		return nil
+	}
+	klog.V(4).Infof("Deleting deployment %s/%s", owner.GetNamespace(), name)
+	err = c.kubeclientset.AppsV1().Deployments(owner.GetNamespace()).Delete(name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// syncService creates the desired Service unless it already exists, in which
+// case it must be controlled by the owner or be adopted by it.
+func (c *Controller) syncService(owner ownerObject, desired *v1core.Service) (*v1core.Service, error) {
+	service, err := c.servicesLister.Services(desired.Namespace).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		klog.Infof("not found target website service %s, about to create", desired.Name)
+		service, err = c.kubeclientset.CoreV1().Services(desired.Namespace).Create(desired)
 		// If an error occurs during Get/Create, we'll requeue the item so we can
 		// attempt processing again later. This could have been caused by a
 		// temporary network failure, or any other transient reason.
 		if err != nil {
-			return err
+			return nil, err
 		}
-		klog.Info("target website service created: %v", targetService)
+		klog.Infof("target website service created: %v", service)
+		return service, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(service, owner) {
+		if adoptsExisting(owner, service) {
+			return c.adoptService(owner, service, desired)
+		}
+		msg := fmt.Sprintf(MessageResourceExists, service.Name)
+		c.recorder.Event(owner, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return nil, fmt.Errorf(msg)
+	}
+	klog.V(4).Infof("target service found: %v", service)
 
-	} else {
-		klog.Infof("target service found: %v", webSiteService)
+	// The selector switches between pods, e.g. the colors of a blue/green
+	// website, and the type may be restricted by a policy, so keep them in
+	// line with the desired ones. So do the target ports, which Services
+	// created before the named port lack.
+	serviceCopy := service.DeepCopy()
+	serviceCopy.Spec.Selector = desired.Spec.Selector
+	if serviceCopy.Spec.Type != desired.Spec.Type {
+		// A ClusterIP Service may not keep the node ports it had
+		serviceCopy.Spec.Type = desired.Spec.Type
+		if desired.Spec.Type == v1core.ServiceTypeClusterIP {
+			for i := range serviceCopy.Spec.Ports {
+				serviceCopy.Spec.Ports[i].NodePort = 0
+			}
+		}
+	}
+	if len(serviceCopy.Spec.Ports) == len(desired.Spec.Ports) {
+		for i := range serviceCopy.Spec.Ports {
+			serviceCopy.Spec.Ports[i].TargetPort = desired.Spec.Ports[i].TargetPort
+		}
+	}
+	if !equality.Semantic.DeepEqual(service.Spec, serviceCopy.Spec) {
+		klog.V(4).Infof("Service %s selector: %v, desired selector: %v", service.Name, service.Spec.Selector, desired.Spec.Selector)
+		return c.kubeclientset.CoreV1().Services(desired.Namespace).Update(serviceCopy)
+	}
+	return service, nil
+}
+
+// syncDeployment creates the desired Deployment, or brings the existing one
+// in line with it when the replicas or the served revision differ.
+func (c *Controller) syncDeployment(owner ownerObject, desired *appsv1.Deployment) (*appsv1.Deployment, error) {
+	// Roll the pods when a Secret they mount changes
+	if err := c.stampSecretHash(owner, &desired.Spec.Template); err != nil {
+		return nil, err
 	}
 
-	// Get the deployment with the name specified in Website.spec
-	deployment, err := c.deploymentsLister.Deployments(website.Namespace).Get(deploymentName)
+	deployment, err := c.deploymentsLister.Deployments(desired.Namespace).Get(desired.Name)
 	// If the resource doesn't exist, we'll create it
 	if errors.IsNotFound(err) {
-		deployment, err = c.kubeclientset.AppsV1().Deployments(website.Namespace).Create(newDeployment(website))
+		deployment, err = c.kubeclientset.AppsV1().Deployments(desired.Namespace).Create(desired)
+		if err == nil {
+			c.recordPodTemplateOverrides(owner, desired, nil)
+		}
 	}
 
 	// If an error occurs during Get/Create, we'll requeue the item so we can
 	// attempt processing again later. This could have been caused by a
 	// temporary network failure, or any other transient reason.
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// If the Deployment is not controlled by this website resource, we should log
-	// a warning to the event recorder and return error msg.
-	if !metav1.IsControlledBy(deployment, website) {
+	// a warning to the event recorder and return error msg, unless the
+	// website adopts it.
+	if !metav1.IsControlledBy(deployment, owner) {
+		if adoptsExisting(owner, deployment) {
+			return c.adoptDeployment(owner, deployment, desired)
+		}
 		msg := fmt.Sprintf(MessageResourceExists, deployment.Name)
-		c.recorder.Event(website, corev1.EventTypeWarning, ErrResourceExists, msg)
-		return fmt.Errorf(msg)
+		c.recorder.Event(owner, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return nil, fmt.Errorf(msg)
 	}
+	c.recordPodTemplateOverrides(owner, desired, deployment)
 
 	// If this number of the replicas on the website resource is specified, and the
 	// number does not equal the current desired replicas on the Deployment, we
-	// should update the Deployment resource.
-	if website.Spec.Replicas != nil && *website.Spec.Replicas != *deployment.Spec.Replicas {
-		klog.V(4).Infof("Foo %s replicas: %d, deployment replicas: %d", name, *website.Spec.Replicas, *deployment.Spec.Replicas)
-		deployment, err = c.kubeclientset.AppsV1().Deployments(website.Namespace).Update(newDeployment(website))
+	// should update the Deployment resource. The same goes for a new revision,
+	// when the branch moved to another commit or the website is rolled back,
+	// and for a changed build, server configuration, mounted Secret,
+	// resources, pod template overrides, source, sources or content path.
+	if desired.Spec.Replicas != nil && *desired.Spec.Replicas != *deployment.Spec.Replicas {
+		klog.V(4).Infof("Deployment %s desired replicas: %d, deployment replicas: %d", desired.Name, *desired.Spec.Replicas, *deployment.Spec.Replicas)
+		return c.kubeclientset.AppsV1().Deployments(desired.Namespace).Update(desired)
 	}
-
-	//TODO: need to update svc?
-
-	// If an error occurs during Update, we'll requeue the item so we can
-	// attempt processing again later. THis could have been caused by a
-	// temporary network failure, or any other transient reason.
-	if err != nil {
-		return err
+	for _, annotation := range []string{revisionAnnotation, buildAnnotation, configHashAnnotation, secretHashAnnotation, resourcesAnnotation, overridesHashAnnotation, sourceAnnotation, sourcesAnnotation, contentPathAnnotation} {
+		desiredValue := desired.Spec.Template.Annotations[annotation]
+		currentValue := deployment.Spec.Template.Annotations[annotation]
+		if desiredValue != currentValue {
+			klog.V(4).Infof("Deployment %s desired %s: %q, deployment %s: %q", desired.Name, annotation, desiredValue, annotation, currentValue)
+			return c.kubeclientset.AppsV1().Deployments(desired.Namespace).Update(desired)
+		}
 	}
-
-	// Finally, we update the status block of the website resource to reflect the
-	// current state of the world
-	err = c.updateWebsiteStatus(website, deployment)
-	if err != nil {
-		return err
+	// The scheduling fields and rollout settings are compared as they are,
+	// so hand edits of them are reverted as well
+	if schedulingDrifted(&desired.Spec.Template.Spec, &deployment.Spec.Template.Spec) {
+		klog.V(4).Infof("Deployment %s scheduling drifted from the website spec", desired.Name)
+		return c.kubeclientset.AppsV1().Deployments(desired.Namespace).Update(desired)
 	}
-
-	c.recorder.Event(website, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
-	return nil
+	if deploymentSettingsDrifted(desired, deployment) {
+		klog.V(4).Infof("Deployment %s rollout settings drifted from the website spec", desired.Name)
+		return c.kubeclientset.AppsV1().Deployments(desired.Namespace).Update(desired)
+	}
+	return deployment, nil
 }
 
-func (c *Controller) updateWebsiteStatus(website *myv1alpha1.Website, deployment *appsv1.Deployment) error {
+func (c *Controller) updateWebsiteStatus(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, deployment *appsv1.Deployment) error {
 	// NEVER modify objects from the store. It's a read-only, local cache.
 	// You can use DeepCopy() to make a deep copy of original object and modify this copy
 	// Or create a copy manually for better performance
 	websiteCopy := website.DeepCopy()
-	websiteCopy.Status.AvailableReplicas = deployment.Status.AvailableReplicas
+	websiteCopy.Status = *status
+	websiteCopy.Status.AvailableReplicas = 0
+	if deployment != nil {
+		websiteCopy.Status.AvailableReplicas = deployment.Status.AvailableReplicas
+	}
+	if equality.Semantic.DeepEqual(website.Status, websiteCopy.Status) {
+		return nil
+	}
+	if isClusterWebsite(website) {
+		clusterWebsite, err := c.clusterWebsitesLister.Get(website.Name)
+		if err != nil {
+			return err
+		}
+		clusterWebsiteCopy := clusterWebsite.DeepCopy()
+		clusterWebsiteCopy.Status = websiteCopy.Status
+		_, err = c.sampleclientset.MycontrollerV1alpha1().ClusterWebsites().Update(clusterWebsiteCopy)
+		return err
+	}
 	// If the CustomResourceSubresources feature gate is not enabled,
 	// we must use Update instead of UpdateStatus to update the Status block of the Foo resource.
 	// UpdateStatus will not allow changes to the Spec of the resource,
@@ -368,6 +1283,19 @@ func (c *Controller) updateWebsiteStatus(website *myv1alpha1.Website, deployment
 	return err
 }
 
+// updateUnsyncedStatus updates the status of a website that is not synced,
+// reporting the available replicas of what already runs.
+func (c *Controller) updateUnsyncedStatus(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus) error {
+	deployment, err := c.deploymentsLister.Deployments(website.Namespace).Get(website.Spec.DeploymentName)
+	if errors.IsNotFound(err) {
+		deployment, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.updateWebsiteStatus(website, status, deployment)
+}
+
 // enqueueWebsite takes a Foo resource and converts it into a namespace/name
 // string which is then put onto the work queue. This method should *not* be
 // passed resources of any type other than Foo.
@@ -404,6 +1332,25 @@ func (c *Controller) handleObject(obj interface{}) {
 	}
 	klog.V(4).Infof("Processing object: %s", object.GetName())
 	if ownerRef := metav1.GetControllerOf(object); ownerRef != nil {
+		// Objects of a preview are handed to the preview workqueue
+		if ownerRef.Kind == "WebsitePreview" {
+			preview, err := c.previewsLister.WebsitePreviews(object.GetNamespace()).Get(ownerRef.Name)
+			if err != nil {
+				klog.V(4).Infof("ignoring orphaned object '%s' of preview '%s'", object.GetSelfLink(), ownerRef.Name)
+				return
+			}
+			c.enqueuePreview(preview)
+			return
+		}
+		if ownerRef.Kind == clusterWebsiteKind {
+			clusterWebsite, err := c.clusterWebsitesLister.Get(ownerRef.Name)
+			if err != nil {
+				klog.V(4).Infof("ignoring orphaned object '%s' of cluster website '%s'", object.GetSelfLink(), ownerRef.Name)
+				return
+			}
+			c.enqueueWebsite(clusterWebsite)
+			return
+		}
 		// If this object is not owned by a Foo, we should not do anything more
 		// with it.
 		if ownerRef.Kind != "Website" {
@@ -421,11 +1368,23 @@ func (c *Controller) handleObject(obj interface{}) {
 	}
 }
 
+// websiteBranch returns the git branch a Website serves.
+func websiteBranch(website *myv1alpha1.Website) string {
+	if git := websiteGitSource(website); git != nil && git.Branch != "" {
+		return git.Branch
+	}
+	return defaultBranch
+}
+
+// websiteServiceName returns the name of the service fronting the website.
+func websiteServiceName(website *myv1alpha1.Website) string {
+	// use deploynment name for service name
+	return fmt.Sprintf("%s-%s", website.Spec.DeploymentName, "npsvc")
+}
+
 // newService creates a new service for website deployment
 func newService(website *myv1alpha1.Website) *v1core.Service {
-	deploymentName := website.Spec.DeploymentName
-	// use deploynment name for service name
-	serviceName := fmt.Sprintf("%s-%s", deploymentName, "npsvc")
+	serviceName := websiteServiceName(website)
 	labels := map[string]string{
 		"app":        "website",
 		"controller": website.Name,
@@ -440,16 +1399,17 @@ func newService(website *myv1alpha1.Website) *v1core.Service {
 			Namespace: website.Namespace,
 			Labels:    labels,
 			OwnerReferences: []metav1.OwnerReference{
-				*metav1.NewControllerRef(website, myv1alpha1.SchemeGroupVersion.WithKind("Website")),
+				*websiteControllerRef(website),
 			},
 		},
 		Spec: v1core.ServiceSpec{
 			Ports: []v1core.ServicePort{
 				{
-					Port:     80,
-					Protocol: v1core.ProtocolTCP,
+					Port:       httpPort,
+					TargetPort: intstr.FromString(httpPortName),
+					Protocol:   v1core.ProtocolTCP,
 				}},
-			Type:     v1core.ServiceTypeNodePort,
+			Type:     websiteServiceType(website),
 			Selector: selectLabels,
 		},
 	}
@@ -457,18 +1417,22 @@ func newService(website *myv1alpha1.Website) *v1core.Service {
 
 // newDeployment creates a new Deployment for a Website resource. It also sets
 // the appropriate OwnerReferences on the resource so handleObject can discover
-// the website resource that 'owns' it.
-func newDeployment(website *myv1alpha1.Website) *appsv1.Deployment {
+// the website resource that 'owns' it. The pods are pinned to revision, or
+// follow the branch head when no revision was resolved yet.
+func newDeployment(website *myv1alpha1.Website, revision string) *appsv1.Deployment {
 	labels := map[string]string{
 		"app":        "website-nginx",
 		"controller": website.Name,
 	}
-	return &appsv1.Deployment{
+	podAnnotations := map[string]string{
+		revisionAnnotation: revision,
+	}
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      website.Spec.DeploymentName,
 			Namespace: website.Namespace,
 			OwnerReferences: []metav1.OwnerReference{
-				*metav1.NewControllerRef(website, myv1alpha1.SchemeGroupVersion.WithKind("Website")),
+				*websiteControllerRef(website),
 			},
 		},
 		Spec: appsv1.DeploymentSpec{
@@ -478,61 +1442,12 @@ func newDeployment(website *myv1alpha1.Website) *appsv1.Deployment {
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: podAnnotations,
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
-						{
-							// nginx container for hosting website
-							Name:  "nginx",
-							Image: "nginx",
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "html",
-									MountPath: "/usr/share/nginx/html",
-									ReadOnly:  true,
-								},
-							},
-							Ports: []corev1.ContainerPort{
-								{
-									ContainerPort: 80,
-									Protocol:      "TCP",
-								},
-							},
-						},
-						{
-							// git sync container for fetching code
-							Name:  "git-sync",
-							Image: "openweb/git-sync",
-							Env: []corev1.EnvVar{
-								{
-									Name:  "GIT_SYNC_REPO",
-									Value: website.Spec.GitRepo,
-								},
-								{
-									Name:  "GIT_SYNC_DEST",
-									Value: "/gitrepo",
-								},
-								{
-									Name:  "GIT_SYNC_BRANCH",
-									Value: "master",
-								},
-								{
-									Name:  "GIT_SYNC_REV",
-									Value: "FETCH_HEAD",
-								},
-								{
-									Name:  "GIT_SYNC_WAIT",
-									Value: "3600",
-								},
-							},
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "html",
-									MountPath: "/gitrepo",
-								},
-							},
-						},
+						backendFor(website).Container(website),
 					},
 					Volumes: []corev1.Volume{
 						{
@@ -548,4 +1463,25 @@ func newDeployment(website *myv1alpha1.Website) *appsv1.Deployment {
 			},
 		},
 	}
+	// Build the site from the checkout instead of serving it as is
+	if website.Spec.Build != nil {
+		applyBuild(&deployment.Spec.Template, website, revision)
+	}
+	applySources(&deployment.Spec.Template, website, revision)
+	// The managed server configuration sets the root itself
+	if serverConfigManaged(website) {
+		applyServerConfig(&deployment.Spec.Template, website)
+	} else if website.Spec.Build == nil {
+		applyContentPath(&deployment.Spec.Template, website)
+	}
+	applyResources(&deployment.Spec.Template, website)
+	applyScheduling(&deployment.Spec.Template, website)
+	applySecurity(&deployment.Spec.Template, website)
+	applyDeploymentSettings(deployment, website)
+	// The overrides go last to see the whole generated template
+	if err := applyPodTemplateOverrides(deployment, website); err != nil {
+		// Rejected by validatePodTemplateOverrides before the sync already
+		utilruntime.HandleError(err)
+	}
+	return deployment
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+	myv1beta1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1beta1"
+)
+
+// conversionReview is the ConversionReview of apiextensions.k8s.io/v1beta1
+// the API server sends to the conversion webhook of a CRD. The
+// apiextensions module is not a dependency, so it is declared here.
+type conversionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *conversionRequest  `json:"request,omitempty"`
+	Response        *conversionResponse `json:"response,omitempty"`
+}
+
+type conversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+type conversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// conversionHandler is the conversion webhook of the Website CRD, converting
+// Websites between v1alpha1 and v1beta1.
+type conversionHandler struct{}
+
+func (h conversionHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxAdmissionReview))
+	if err != nil {
+		http.Error(w, "unable to read conversion review", http.StatusBadRequest)
+		return
+	}
+	review := &conversionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "malformed conversion review", http.StatusBadRequest)
+		return
+	}
+
+	response := &conversionResponse{UID: review.Request.UID, Result: metav1.Status{Status: metav1.StatusSuccess}}
+	for _, object := range review.Request.Objects {
+		converted, err := convertWebsite(object.Raw, review.Request.DesiredAPIVersion)
+		if err != nil {
+			klog.V(2).Infof("Failed to convert to %s: %v", review.Request.DesiredAPIVersion, err)
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+			break
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+	review.Response = response
+	review.Request = nil
+	out, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+// convertWebsite converts the JSON of a Website to apiVersion.
+func convertWebsite(data []byte, apiVersion string) ([]byte, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(data, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.Kind != "Website" {
+		return nil, fmt.Errorf("unexpected kind %q", typeMeta.Kind)
+	}
+	alpha, beta := myv1alpha1.SchemeGroupVersion.String(), myv1beta1.SchemeGroupVersion.String()
+	switch {
+	case typeMeta.APIVersion == apiVersion:
+		return data, nil
+	case typeMeta.APIVersion == alpha && apiVersion == beta:
+		in, out := &myv1alpha1.Website{}, &myv1beta1.Website{}
+		if err := json.Unmarshal(data, in); err != nil {
+			return nil, err
+		}
+		if err := myv1beta1.Convert_v1alpha1_Website_To_v1beta1_Website(in, out, nil); err != nil {
+			return nil, err
+		}
+		return json.Marshal(out)
+	case typeMeta.APIVersion == beta && apiVersion == alpha:
+		in, out := &myv1beta1.Website{}, &myv1alpha1.Website{}
+		if err := json.Unmarshal(data, in); err != nil {
+			return nil, err
+		}
+		if err := myv1beta1.Convert_v1beta1_Website_To_v1alpha1_Website(in, out, nil); err != nil {
+			return nil, err
+		}
+		return json.Marshal(out)
+	}
+	return nil, fmt.Errorf("cannot convert %s to %s", typeMeta.APIVersion, apiVersion)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	// maxGitTasks bounds the git calls running at once.
+	maxGitTasks = 8
+
+	// gitTaskResults bounds the results kept by key.
+	gitTaskResults = 1024
+
+	// gitTaskErrorTTL is how long a failed git call is remembered before a
+	// sync asking for it again starts it again.
+	gitTaskErrorTTL = minResolveInterval
+)
+
+// gitTaskResult is the outcome of a git call.
+type gitTaskResult struct {
+	value interface{}
+	err   error
+}
+
+// gitTasks runs the git calls reaching out to git hosts, ls-remote and
+// fetch, in the background, so a slow or unreachable host never holds up a
+// worker. A sync asks for the result of a call by key: the first ask starts
+// the call, the next ones find it running, and once it returns the done
+// funcs of all of them requeue their objects, whose syncs then find the
+// result.
+type gitTasks struct {
+	mu sync.Mutex
+	// running holds the done funcs of the running calls
+	running map[string][]func()
+	results *utilcache.LRUExpireCache
+	// slots holds a token per running call
+	slots chan struct{}
+}
+
+func newGitTasks() *gitTasks {
+	return &gitTasks{
+		running: map[string][]func(){},
+		results: utilcache.NewLRUExpireCache(gitTaskResults),
+		slots:   make(chan struct{}, maxGitTasks),
+	}
+}
+
+// Do returns the result of the call key and true when it returned. Otherwise
+// it starts run unless it runs already, and returns false; done is called
+// once run returned. A value is kept for ttl, an error for gitTaskErrorTTL,
+// so a call keyed by what it asks for is shared by the syncs asking the same
+// within that time.
+func (t *gitTasks) Do(key string, ttl time.Duration, run func(ctx context.Context) (interface{}, error), done func()) (interface{}, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if result, ok := t.results.Get(key); ok {
+		r := result.(gitTaskResult)
+		return r.value, true, r.err
+	}
+	if waiting, ok := t.running[key]; ok {
+		t.running[key] = append(waiting, done)
+		return nil, false, nil
+	}
+	t.running[key] = []func(){done}
+	go func() {
+		t.slots <- struct{}{}
+		ctx, cancel := context.WithTimeout(context.Background(), lsRemoteTimeout)
+		value, err := run(ctx)
+		cancel()
+		<-t.slots
+
+		t.mu.Lock()
+		if err != nil {
+			t.results.Add(key, gitTaskResult{err: err}, gitTaskErrorTTL)
+		} else {
+			t.results.Add(key, gitTaskResult{value: value}, ttl)
+		}
+		waiting := t.running[key]
+		delete(t.running, key)
+		t.mu.Unlock()
+		for _, done := range waiting {
+			done()
+		}
+	}()
+	return nil, false, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestGitTasks(t *testing.T) {
+	tasks := newGitTasks()
+	release := make(chan struct{})
+	done := make(chan string, 2)
+	calls := 0
+	run := func(ctx context.Context) (interface{}, error) {
+		calls++
+		<-release
+		return "deadbeef", nil
+	}
+
+	if _, ok, _ := tasks.Do("a", time.Minute, run, func() { done <- "first" }); ok {
+		t.Fatal("Do() returned a result before the call returned")
+	}
+	if _, ok, _ := tasks.Do("a", time.Minute, run, func() { done <- "second" }); ok {
+		t.Fatal("Do() returned a result of a running call")
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatal("done was not called for every Do of the running call")
+		}
+	}
+	value, ok, err := tasks.Do("a", time.Minute, run, func() {})
+	if !ok || err != nil || value != "deadbeef" {
+		t.Fatalf("Do() = %v, %v, %v, want deadbeef, true, nil", value, ok, err)
+	}
+	if calls != 1 {
+		t.Errorf("run called %d times, want 1", calls)
+	}
+
+	if _, ok, _ := tasks.Do("b", time.Minute, func(context.Context) (interface{}, error) {
+		return nil, errors.New("unreachable")
+	}, func() { done <- "b" }); ok {
+		t.Fatal("Do() returned a result before the call returned")
+	}
+	<-done
+	if _, ok, err := tasks.Do("b", time.Minute, run, func() {}); !ok || err == nil {
+		t.Fatalf("Do() = %v, %v, want the error of the call", ok, err)
+	}
+}
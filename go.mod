@@ -3,10 +3,12 @@ module github.com/nevermosby/my-crd-controller
 go 1.13
 
 require (
+	github.com/google/gofuzz v1.0.0
 	k8s.io/api v0.0.0-20191031065753-b19d8caf39be
 	k8s.io/apimachinery v0.0.0-20191105185716-00d39968b57e
 	k8s.io/client-go v0.0.0-20191101230044-e9766ae82012
 	k8s.io/code-generator v0.0.0-20191029223907-9f431a56fdbc
 	k8s.io/klog v1.0.0
 	k8s.io/sample-controller v0.0.0-20191101231324-472018681a2b
+	sigs.k8s.io/yaml v1.1.0
 )
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// httpdModules are the modules loaded by the rendered httpd.conf.
+var httpdModules = []string{
+	"mpm_event", "unixd", "log_config", "mime", "dir", "autoindex",
+	"authn_core", "authn_file", "authz_core", "authz_host", "authz_user", "auth_basic",
+	"headers", "rewrite", "filter", "deflate",
+}
+
+// httpdBackend serves websites with the official httpd image. Its rendered
+// configuration replaces the stock httpd.conf, which would otherwise pull
+// in modules and settings the website does not ask for.
+type httpdBackend struct{}
+
+func (httpdBackend) Name() string { return string(myv1alpha1.ServerTypeHTTPD) }
+
+func (httpdBackend) HTMLDir() string { return "/usr/local/apache2/htdocs" }
+
+func (httpdBackend) ConfigKey() string { return "httpd.conf" }
+
+// ConfigMount mounts the single file, as conf holds mime.types as well. The
+// file is not updated in place, which is fine since a changed configuration
+// rolls the pods.
+func (b httpdBackend) ConfigMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		MountPath: "/usr/local/apache2/conf/httpd.conf",
+		SubPath:   b.ConfigKey(),
+	}
+}
+
+// WritableDirs is empty, the rendered configuration points the pid file
+// and runtime directory of hardened pods at /tmp.
+func (httpdBackend) WritableDirs() []string { return nil }
+
+func (b httpdBackend) Container(website *myv1alpha1.Website) corev1.Container {
+	return corev1.Container{
+		Name:         b.Name(),
+		Image:        "httpd:2.4",
+		VolumeMounts: []corev1.VolumeMount{htmlMount(b)},
+		Ports:        []corev1.ContainerPort{serverContainerPort(website)},
+	}
+}
+
+// RenderConfig renders httpd.conf. Location sections are merged in order,
+// so with prefixes sorted first the more specific headers win.
+func (b httpdBackend) RenderConfig(website *myv1alpha1.Website) string {
+	server := website.Spec.Server
+	if server == nil {
+		server = &myv1alpha1.WebsiteServer{}
+	}
+	root := serverRoot(b, website)
+
+	var w strings.Builder
+	w.WriteString("ServerRoot \"/usr/local/apache2\"\n")
+	fmt.Fprintf(&w, "Listen %d\n", serverPort(website))
+	for _, module := range httpdModules {
+		fmt.Fprintf(&w, "LoadModule %s_module modules/mod_%s.so\n", module, module)
+	}
+	if websiteHardened(website) {
+		// Already unprivileged, with a read-only ServerRoot
+		fmt.Fprintf(&w, "PidFile %s/httpd.pid\n", tmpDir)
+		fmt.Fprintf(&w, "DefaultRuntimeDir %s\n", tmpDir)
+	} else {
+		w.WriteString("User daemon\n")
+		w.WriteString("Group daemon\n")
+	}
+	w.WriteString("ServerName localhost\n")
+	w.WriteString("ErrorLog /proc/self/fd/2\n")
+	w.WriteString("LogFormat \"%h %l %u %t \\\"%r\\\" %>s %b\" common\n")
+	w.WriteString("CustomLog /proc/self/fd/1 common\n")
+	w.WriteString("TypesConfig conf/mime.types\n")
+	w.WriteString("DirectoryIndex index.html index.htm\n")
+	fmt.Fprintf(&w, "DocumentRoot %s\n", quoteString(root))
+
+	fmt.Fprintf(&w, "<Directory %s>\n", quoteString(root))
+	if server.DirectoryListing {
+		w.WriteString("    Options Indexes FollowSymLinks\n")
+	} else {
+		w.WriteString("    Options FollowSymLinks\n")
+	}
+	w.WriteString("    AllowOverride None\n")
+	w.WriteString("    Require all granted\n")
+	if server.SPAFallback {
+		w.WriteString("    FallbackResource /index.html\n")
+	}
+	w.WriteString("</Directory>\n")
+
+	if server.Gzip {
+		w.WriteString("AddOutputFilterByType DEFLATE text/plain text/css text/xml application/javascript application/json application/xml image/svg+xml\n")
+	}
+	if server.NotFoundPage != "" {
+		fmt.Fprintf(&w, "ErrorDocument 404 %s\n", server.NotFoundPage)
+	}
+	if len(server.Redirects) > 0 {
+		w.WriteString("RewriteEngine On\n")
+	}
+	for _, redirect := range server.Redirects {
+		flags := "R=301,L"
+		switch {
+		case redirect.Rewrite:
+			flags = "PT,L"
+		case redirect.Code == 302:
+			flags = "R=302,L"
+		}
+		fmt.Fprintf(&w, "RewriteRule %s %s [%s]\n", quoteString(redirect.From), quoteString(redirect.To), flags)
+	}
+
+	locations, headers := serverLocations(website)
+	for _, location := range locations {
+		fmt.Fprintf(&w, "\n<Location %s>\n", quoteString(location))
+		if (location == "/" && siteAuth(website)) || locationAuth(website, location) {
+			w.WriteString("    AuthType Basic\n")
+			fmt.Fprintf(&w, "    AuthName %s\n", quoteString(authRealm(website.Spec.Auth)))
+			fmt.Fprintf(&w, "    AuthUserFile %s\n", path.Join(authDir, authFileName))
+			w.WriteString("    Require valid-user\n")
+		}
+		for _, name := range sortedKeys(headers[location]) {
+			fmt.Fprintf(&w, "    Header always set %s %s\n", name, quoteString(headers[location][name]))
+		}
+		w.WriteString("</Location>\n")
+	}
+	return w.String()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// syncIngress exposes the website on spec.ingress.host, and removes the
+// Ingress it owns once spec.ingress is unset.
+func (c *Controller) syncIngress(website *myv1alpha1.Website) error {
+	if website.Spec.Ingress == nil {
+		return c.deleteIngress(website, website.Spec.DeploymentName)
+	}
+	_, err := c.syncIngressObject(website, newIngress(website))
+	return err
+}
+
+// syncIngressObject creates the desired Ingress, or updates the existing one
+// when its rules or annotations drifted.
+func (c *Controller) syncIngressObject(owner ownerObject, desired *networkingv1beta1.Ingress) (*networkingv1beta1.Ingress, error) {
+	ingress, err := c.ingressesLister.Ingresses(desired.Namespace).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		return c.kubeclientset.NetworkingV1beta1().Ingresses(desired.Namespace).Create(desired)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(ingress, owner) {
+		msg := fmt.Sprintf(MessageResourceExists, ingress.Name)
+		c.recorder.Event(owner, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return nil, fmt.Errorf(msg)
+	}
+	if equality.Semantic.DeepEqual(ingress.Spec, desired.Spec) && equality.Semantic.DeepEqual(ingress.Annotations, desired.Annotations) {
+		return ingress, nil
+	}
+	klog.V(4).Infof("Ingress %s/%s drifted, updating", desired.Namespace, desired.Name)
+	ingressCopy := ingress.DeepCopy()
+	ingressCopy.Spec = desired.Spec
+	ingressCopy.Annotations = desired.Annotations
+	return c.kubeclientset.NetworkingV1beta1().Ingresses(desired.Namespace).Update(ingressCopy)
+}
+
+// deleteIngress deletes the named Ingress if the owner controls it.
+func (c *Controller) deleteIngress(owner ownerObject, name string) error {
+	ingress, err := c.ingressesLister.Ingresses(owner.GetNamespace()).Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(ingress, owner) {
+		return nil
+	}
+	err = c.kubeclientset.NetworkingV1beta1().Ingresses(owner.GetNamespace()).Delete(name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// newIngress creates a new Ingress routing spec.ingress.host to the website
+// service.
+func newIngress(website *myv1alpha1.Website) *networkingv1beta1.Ingress {
+	labels := map[string]string{
+		"app":        "website",
+		"controller": website.Name,
+	}
+	var annotations map[string]string
+	if len(website.Spec.Ingress.Annotations) > 0 {
+		annotations = make(map[string]string, len(website.Spec.Ingress.Annotations))
+		for k, v := range website.Spec.Ingress.Annotations {
+			annotations[k] = v
+		}
+	}
+	return &networkingv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        website.Spec.DeploymentName,
+			Namespace:   website.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*websiteControllerRef(website),
+			},
+		},
+		Spec: networkingv1beta1.IngressSpec{
+			Rules: []networkingv1beta1.IngressRule{
+				{
+					Host: website.Spec.Ingress.Host,
+					IngressRuleValue: networkingv1beta1.IngressRuleValue{
+						HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+							Paths: []networkingv1beta1.HTTPIngressPath{
+								{
+									Path: "/",
+									Backend: networkingv1beta1.IngressBackend{
+										ServiceName: websiteServiceName(website),
+										ServicePort: intstr.FromInt(80),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
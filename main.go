@@ -2,9 +2,11 @@ package main
 
 import (
 	"flag"
+	"net/http"
 	"os"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
@@ -14,6 +16,7 @@ import (
 
 	// clientset "k8s.io/sample-controller/pkg/generated/clientset/versioned"
 	// informers "k8s.io/sample-controller/pkg/generated/informers/externalversions"
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
 	clientset "github.com/nevermosby/my-crd-controller/pkg/client/clientset/versioned"
 	informers "github.com/nevermosby/my-crd-controller/pkg/client/informers/externalversions"
 	"k8s.io/sample-controller/pkg/signals"
@@ -22,11 +25,37 @@ import (
 var (
 	masterURL  string
 	kubeconfig string
+
+	webhookAddr   string
+	webhookSecret string
+
+	admissionAddr string
+	tlsCertFile   string
+	tlsKeyFile    string
+
+	gitPollInterval time.Duration
+
+	renderFile string
+
+	effectiveSpec string
 )
 
 func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
+	if err := validatePodSecurityMode("-default-pod-security", myv1alpha1.PodSecurityMode(defaultPodSecurity)); err != nil {
+		klog.Fatal(err)
+	}
+	if _, err := metav1.ParseToLabelSelector(ingressControllerNamespaces); err != nil {
+		klog.Fatalf("-ingress-controller-namespaces: %s", err.Error())
+	}
+
+	if renderFile != "" {
+		if err := renderWebsite(renderFile, os.Stdout); err != nil {
+			klog.Fatalf("Error rendering website: %s", err.Error())
+		}
+		return
+	}
 
 	// set up signals so we handle the first shutdown signal gracefully
 	stopCh := signals.SetupSignalHandler()
@@ -48,25 +77,114 @@ func main() {
 		klog.Fatalf("Error building example clientset: %s", err.Error())
 	}
 
+	if effectiveSpec != "" {
+		if err := printEffectiveSpec(exampleClient, effectiveSpec, os.Stdout); err != nil {
+			klog.Fatalf("Error resolving website: %s", err.Error())
+		}
+		return
+	}
+
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Second*30)
 	exampleInformerFactory := informers.NewSharedInformerFactory(exampleClient, time.Second*30)
 
 	controller := NewController(kubeClient, exampleClient,
 		kubeInformerFactory.Apps().V1().Deployments(),
 		kubeInformerFactory.Core().V1().Services(),
-		exampleInformerFactory.Mycontroller().V1alpha1().Websites())
+		kubeInformerFactory.Core().V1().Pods(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().Namespaces(),
+		kubeInformerFactory.Networking().V1beta1().Ingresses(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Policy().V1beta1().PodDisruptionBudgets(),
+		exampleInformerFactory.Mycontroller().V1alpha1().Websites(),
+		exampleInformerFactory.Mycontroller().V1alpha1().ClusterWebsites(),
+		exampleInformerFactory.Mycontroller().V1alpha1().WebsitePreviews(),
+		exampleInformerFactory.Mycontroller().V1alpha1().WebsitePolicies(),
+		exampleInformerFactory.Mycontroller().V1alpha1().ClusterWebsitePolicies(),
+		exampleInformerFactory.Mycontroller().V1alpha1().WebsiteTemplates(),
+		gitPollInterval)
 
 	// notice that there is no need to run Start methods in a separate goroutine. (i.e. go kubeInformerFactory.Start(stopCh)
 	// Start method is non-blocking and runs all registered informers in a dedicated goroutine.
 	kubeInformerFactory.Start(stopCh)
 	exampleInformerFactory.Start(stopCh)
 
+	if webhookAddr != "" {
+		serveWebhooks(controller, stopCh)
+	}
+	if admissionAddr != "" {
+		serveAdmission(controller, stopCh)
+	}
+
 	if err = controller.Run(2, stopCh); err != nil {
 		klog.Fatalf("Error running controller: %s", err.Error())
 	}
 }
 
+// serveWebhooks starts the git push webhook receiver in the background and
+// shuts it down when stopCh is closed.
+func serveWebhooks(controller *Controller, stopCh <-chan struct{}) {
+	if webhookSecret == "" {
+		webhookSecret = os.Getenv("WEBHOOK_SECRET")
+	}
+	if webhookSecret == "" {
+		klog.Fatal("A webhook secret is required when the webhook receiver is enabled")
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/hooks/git", newWebhookReceiver(webhookSecret, controller))
+	server := &http.Server{Addr: webhookAddr, Handler: mux}
+	go func() {
+		klog.Infof("Serving git push webhooks on %s", webhookAddr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			klog.Fatalf("Error serving webhooks: %s", err.Error())
+		}
+	}()
+	go func() {
+		<-stopCh
+		server.Close()
+	}()
+}
+
+// serveAdmission starts the validating admission webhook and the conversion
+// webhook of Websites in the background and shuts them down when stopCh is
+// closed. The API server only calls webhooks over HTTPS.
+func serveAdmission(controller *Controller, stopCh <-chan struct{}) {
+	if tlsCertFile == "" || tlsKeyFile == "" {
+		klog.Fatal("A TLS certificate and key are required when the admission webhook is enabled")
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/validate", &admissionHandler{controller: controller})
+	mux.Handle("/convert", conversionHandler{})
+	server := &http.Server{Addr: admissionAddr, Handler: mux}
+	go func() {
+		klog.Infof("Serving admission and conversion webhooks on %s", admissionAddr)
+		if err := server.ListenAndServeTLS(tlsCertFile, tlsKeyFile); err != nil && err != http.ErrServerClosed {
+			klog.Fatalf("Error serving admission webhook: %s", err.Error())
+		}
+	}()
+	go func() {
+		<-stopCh
+		server.Close()
+	}()
+}
+
 func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	flag.StringVar(&webhookAddr, "webhook-addr", "", "Address to serve git push webhooks on, e.g. :8080. Disabled when empty.")
+	flag.StringVar(&webhookSecret, "webhook-secret", "", "Shared secret used to verify git push webhooks. Defaults to $WEBHOOK_SECRET.")
+	flag.StringVar(&admissionAddr, "admission-addr", "", "Address to serve the validating admission webhook and the Website conversion webhook on, e.g. :8443. Disabled when empty.")
+	flag.StringVar(&tlsCertFile, "tls-cert-file", "", "TLS certificate of the admission and conversion webhooks.")
+	flag.StringVar(&tlsKeyFile, "tls-key-file", "", "TLS private key of the admission and conversion webhooks.")
+	flag.Var(resourceListFlag{&defaultServerResources.Requests}, "default-server-requests", "Resource requests of the serving container of websites without spec.resources.server, e.g. cpu=10m,memory=32Mi.")
+	flag.Var(resourceListFlag{&defaultServerResources.Limits}, "default-server-limits", "Resource limits of the serving container of websites without spec.resources.server.")
+	flag.Var(resourceListFlag{&defaultSyncResources.Requests}, "default-sync-requests", "Resource requests of the fetch, sync and build containers of websites without spec.resources.sync.")
+	flag.Var(resourceListFlag{&defaultSyncResources.Limits}, "default-sync-limits", "Resource limits of the fetch, sync and build containers of websites without spec.resources.sync.")
+	flag.Var(quantityFlag{&defaultContentSizeLimit}, "default-content-size-limit", "Size limit of the content volumes of websites without spec.resources.contentSizeLimit. Unlimited when 0.")
+	flag.StringVar(&renderFile, "render", "", "Print the Deployment rendered for the Website manifest in this file and exit, e.g. to preview spec.podTemplateOverrides.")
+	flag.StringVar(&effectiveSpec, "effective-spec", "", "Print the effective spec of the Website namespace/name, or of the ClusterWebsite name, merged with its WebsiteTemplate, and exit.")
+	flag.StringVar(&defaultPodSecurity, "default-pod-security", defaultPodSecurity, "Pod security of websites without spec.podSecurity, Hardened or Unrestricted.")
+	flag.StringVar(&ingressControllerNamespaces, "ingress-controller-namespaces", ingressControllerNamespaces, "Label selector of the namespaces of the ingress controller, allowed to reach websites with spec.networkPolicy and spec.ingress.")
+	flag.DurationVar(&gitPollInterval, "git-poll-interval", time.Minute, "How often the branch followed by each Website is resolved to a commit with git ls-remote.")
 }
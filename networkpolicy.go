@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// dnsPort is open for egress to the cluster DNS.
+	dnsPort = 53
+
+	// anyIPv4 is the egress destination without egressCIDRs.
+	anyIPv4 = "0.0.0.0/0"
+)
+
+// ingressControllerNamespaces selects the namespaces of the ingress
+// controller, set with the -ingress-controller-namespaces flag. The label
+// is the one the ingress-nginx manifests set on their namespace.
+var ingressControllerNamespaces = "app.kubernetes.io/name=ingress-nginx"
+
+// validateNetworkPolicy returns an error when spec.networkPolicy holds a
+// malformed CIDR or selector.
+func validateNetworkPolicy(website *myv1alpha1.Website) error {
+	policy := website.Spec.NetworkPolicy
+	if policy == nil {
+		return nil
+	}
+	for i, cidr := range policy.EgressCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("spec.networkPolicy.egressCIDRs[%d]: %v", i, err)
+		}
+	}
+	for i := range policy.AllowedNamespaces {
+		if _, err := metav1.LabelSelectorAsSelector(&policy.AllowedNamespaces[i]); err != nil {
+			return fmt.Errorf("spec.networkPolicy.allowedNamespaces[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// syncNetworkPolicy restricts the traffic of the website pods, and removes
+// the NetworkPolicy it owns once spec.networkPolicy is unset.
+func (c *Controller) syncNetworkPolicy(website *myv1alpha1.Website) error {
+	if website.Spec.NetworkPolicy == nil {
+		return c.deleteNetworkPolicy(website)
+	}
+	desired, err := newNetworkPolicy(website)
+	if err != nil {
+		return err
+	}
+	policy, err := c.networkPoliciesLister.NetworkPolicies(desired.Namespace).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		_, err = c.kubeclientset.NetworkingV1().NetworkPolicies(desired.Namespace).Create(desired)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(policy, website) {
+		msg := fmt.Sprintf(MessageResourceExists, policy.Name)
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+	if equality.Semantic.DeepEqual(policy.Spec, desired.Spec) {
+		return nil
+	}
+	klog.V(4).Infof("NetworkPolicy %s/%s drifted, updating", desired.Namespace, desired.Name)
+	policyCopy := policy.DeepCopy()
+	policyCopy.Spec = desired.Spec
+	_, err = c.kubeclientset.NetworkingV1().NetworkPolicies(desired.Namespace).Update(policyCopy)
+	return err
+}
+
+// deleteNetworkPolicy deletes the NetworkPolicy of the website if it
+// controls it.
+func (c *Controller) deleteNetworkPolicy(website *myv1alpha1.Website) error {
+	name := website.Spec.DeploymentName
+	policy, err := c.networkPoliciesLister.NetworkPolicies(website.Namespace).Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(policy, website) {
+		return nil
+	}
+	err = c.kubeclientset.NetworkingV1().NetworkPolicies(website.Namespace).Delete(name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// newNetworkPolicy creates a new NetworkPolicy letting the ingress
+// controller and spec.networkPolicy.allowedNamespaces reach the server port
+// of the website pods, and the pods reach DNS and their sources.
+func newNetworkPolicy(website *myv1alpha1.Website) (*networkingv1.NetworkPolicy, error) {
+	spec := website.Spec.NetworkPolicy
+	var peers []networkingv1.NetworkPolicyPeer
+	if website.Spec.Ingress != nil {
+		selector, err := metav1.ParseToLabelSelector(ingressControllerNamespaces)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, networkingv1.NetworkPolicyPeer{NamespaceSelector: selector})
+	}
+	for i := range spec.AllowedNamespaces {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{NamespaceSelector: spec.AllowedNamespaces[i].DeepCopy()})
+	}
+	httpPort := intstr.FromString(httpPortName)
+	tcp, udp := corev1.ProtocolTCP, corev1.ProtocolUDP
+	var ingress []networkingv1.NetworkPolicyIngressRule
+	if len(peers) > 0 {
+		ingress = []networkingv1.NetworkPolicyIngressRule{
+			{
+				Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &httpPort}},
+				From:  peers,
+			},
+		}
+	}
+
+	dns := intstr.FromInt(dnsPort)
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &dns}, {Protocol: &tcp, Port: &dns}},
+			To:    []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}},
+		},
+	}
+	if ports := sourcePorts(website); len(ports) > 0 {
+		rule := networkingv1.NetworkPolicyEgressRule{}
+		for _, port := range ports {
+			port := intstr.FromInt(port)
+			rule.Ports = append(rule.Ports, networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: &port})
+		}
+		cidrs := spec.EgressCIDRs
+		if len(cidrs) == 0 {
+			cidrs = []string{anyIPv4}
+		}
+		for _, cidr := range cidrs {
+			rule.To = append(rule.To, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+		}
+		egress = append(egress, rule)
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      website.Spec.DeploymentName,
+			Namespace: website.Namespace,
+			Labels: map[string]string{
+				"app":        "website",
+				"controller": website.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*websiteControllerRef(website),
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: *websitePodSelector(website),
+			Ingress:     ingress,
+			Egress:      egress,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}, nil
+}
+
+// sourcePorts returns the sorted TCP ports the website pods fetch their
+// sources from. A build may download its dependencies, so it opens the
+// HTTP ports as well.
+func sourcePorts(website *myv1alpha1.Website) []int {
+	set := map[int]bool{}
+	for _, source := range websiteSources(website) {
+		switch {
+		case source.Git != nil:
+			set[gitPort(source.Git.Repo)] = true
+		case source.HTTP != nil:
+			set[urlPort(source.HTTP.URL, 443)] = true
+		case source.OCI != nil:
+			set[registryPort(source.OCI)] = true
+		case source.S3 != nil:
+			set[urlPort(source.S3.Endpoint, 443)] = true
+		}
+	}
+	if website.Spec.Build != nil {
+		set[80], set[443] = true, true
+	}
+	delete(set, 0)
+	ports := make([]int, 0, len(set))
+	for port := range set {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// gitPort returns the port git connects to for repo, 0 for a local one.
+func gitPort(repo string) int {
+	if !strings.Contains(repo, "://") {
+		if strings.Contains(repo, ":") && !strings.HasPrefix(repo, "/") {
+			// scp-like syntax, user@host:path
+			return 22
+		}
+		return 0
+	}
+	u, err := url.Parse(repo)
+	if err != nil {
+		return 0
+	}
+	switch u.Scheme {
+	case "ssh", "git+ssh":
+		return urlPort(repo, 22)
+	case "git":
+		return urlPort(repo, 9418)
+	case "file":
+		return 0
+	}
+	return urlPort(repo, 443)
+}
+
+// urlPort returns the port of rawurl, the default of its scheme or def.
+func urlPort(rawurl string, def int) int {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return def
+	}
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	if u.Scheme == "http" {
+		return 80
+	}
+	return def
+}
+
+// registryPort returns the port of the registry of an OCI artifact.
+func registryPort(oci *myv1alpha1.OCISource) int {
+	host := strings.SplitN(oci.Reference, "/", 2)[0]
+	if _, port, err := net.SplitHostPort(host); err == nil {
+		if p, err := strconv.Atoi(port); err == nil {
+			return p
+		}
+	}
+	if oci.PlainHTTP {
+		return 80
+	}
+	return 443
+}
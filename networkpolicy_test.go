@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+func TestNetworkPolicySelectsWebsitePods(t *testing.T) {
+	website := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia"},
+		Spec: myv1alpha1.WebsiteSpec{
+			DeploymentName: "kubia",
+			GitRepo:        "https://github.com/nevermosby/kubia-website-example.git",
+			NetworkPolicy:  &myv1alpha1.WebsiteNetworkPolicy{},
+		},
+	}
+	policy, err := newNetworkPolicy(website)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		labels labels.Set
+		want   bool
+	}{
+		{name: "website", labels: newDeployment(website, "").Spec.Template.Labels, want: true},
+		{name: "canary", labels: canaryLabels(website), want: true},
+		{name: "blue", labels: colorLabels(website, blue), want: true},
+		{name: "preview", labels: labels.Set{"app": "website-nginx-preview", "controller": "kubia"}},
+		{name: "other workload", labels: labels.Set{"app": "api", "controller": "kubia"}},
+		{name: "other website", labels: labels.Set{"app": "website-nginx", "controller": "docs"}},
+	}
+	for _, tt := range tests {
+		if got := selector.Matches(tt.labels); got != tt.want {
+			t.Errorf("%s pods %v selected = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// nginxBackend serves websites with the official nginx image. Its rendered
+// configuration replaces the stock server block in conf.d.
+type nginxBackend struct{}
+
+func (nginxBackend) Name() string { return string(myv1alpha1.ServerTypeNginx) }
+
+func (nginxBackend) HTMLDir() string { return "/usr/share/nginx/html" }
+
+func (nginxBackend) ConfigKey() string { return "default.conf" }
+
+func (b nginxBackend) ConfigMount() corev1.VolumeMount {
+	return corev1.VolumeMount{MountPath: "/etc/nginx/conf.d"}
+}
+
+// WritableDirs is the cache directory, the unprivileged image keeps its pid
+// and temp files in /tmp already.
+func (nginxBackend) WritableDirs() []string { return []string{"/var/cache/nginx"} }
+
+// Container runs the unprivileged variant of the nginx image in hardened
+// pods, which runs as the nginx user and listens on 8080.
+func (b nginxBackend) Container(website *myv1alpha1.Website) corev1.Container {
+	image := "nginx"
+	if websiteHardened(website) {
+		image = "nginxinc/nginx-unprivileged"
+	}
+	return corev1.Container{
+		// nginx container for hosting website
+		Name:         b.Name(),
+		Image:        image,
+		VolumeMounts: []corev1.VolumeMount{htmlMount(b)},
+		Ports:        []corev1.ContainerPort{serverContainerPort(website)},
+	}
+}
+
+// RenderConfig renders the nginx server block. Each path with headers gets
+// its own location, since add_header directives of a location replace the
+// inherited ones.
+func (b nginxBackend) RenderConfig(website *myv1alpha1.Website) string {
+	server := website.Spec.Server
+	if server == nil {
+		server = &myv1alpha1.WebsiteServer{}
+	}
+
+	var w strings.Builder
+	w.WriteString("server {\n")
+	fmt.Fprintf(&w, "    listen %d;\n", serverPort(website))
+	w.WriteString("    server_name localhost;\n")
+	fmt.Fprintf(&w, "    root %s;\n", serverRoot(b, website))
+	w.WriteString("    index index.html index.htm;\n")
+	if server.Gzip {
+		w.WriteString("    gzip on;\n")
+		w.WriteString("    gzip_min_length 1024;\n")
+		w.WriteString("    gzip_types text/plain text/css text/xml application/javascript application/json application/xml image/svg+xml;\n")
+	}
+	if server.DirectoryListing {
+		w.WriteString("    autoindex on;\n")
+	}
+	if server.NotFoundPage != "" {
+		fmt.Fprintf(&w, "    error_page 404 %s;\n", server.NotFoundPage)
+	}
+	if siteAuth(website) {
+		writeNginxAuth(&w, "    ", website.Spec.Auth)
+	}
+	for _, redirect := range server.Redirects {
+		flag := "permanent"
+		switch {
+		case redirect.Rewrite:
+			flag = "last"
+		case redirect.Code == 302:
+			flag = "redirect"
+		}
+		fmt.Fprintf(&w, "    rewrite %s %s %s;\n", quoteString(redirect.From), quoteString(redirect.To), flag)
+	}
+
+	locations, headers := serverLocations(website)
+	for _, location := range locations {
+		fmt.Fprintf(&w, "\n    location %s {\n", location)
+		if server.SPAFallback {
+			w.WriteString("        try_files $uri $uri/ /index.html;\n")
+		}
+		if locationAuth(website, location) {
+			writeNginxAuth(&w, "        ", website.Spec.Auth)
+		}
+		for _, name := range sortedKeys(headers[location]) {
+			fmt.Fprintf(&w, "        add_header %s %s always;\n", name, quoteString(headers[location][name]))
+		}
+		w.WriteString("    }\n")
+	}
+	w.WriteString("}\n")
+	return w.String()
+}
+
+func writeNginxAuth(w *strings.Builder, indent string, auth *myv1alpha1.WebsiteAuth) {
+	fmt.Fprintf(w, "%sauth_basic %s;\n", indent, quoteString(authRealm(auth)))
+	fmt.Fprintf(w, "%sauth_basic_user_file %s;\n", indent, path.Join(authDir, authFileName))
+}
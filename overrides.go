@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// controllerAnnotationPrefix is the prefix of the annotations the
+	// controller owns, which spec.podTemplateOverrides cannot set.
+	controllerAnnotationPrefix = "mycontroller.nevermosby.io/"
+
+	// overridesAnnotation on the Deployment holds the effective patch of
+	// spec.podTemplateOverrides, the difference it made to the generated
+	// pod template.
+	overridesAnnotation = "mycontroller.nevermosby.io/pod-template-overrides"
+	// overridesHashAnnotation on the pod template records a hash of the
+	// effective patch, so changed overrides are rolled out.
+	overridesHashAnnotation = "mycontroller.nevermosby.io/overrides-hash"
+
+	// overridesEventBytes bounds the patch quoted in events.
+	overridesEventBytes = 512
+)
+
+// mergePodTemplate applies overrides to template as a strategic merge
+// patch. It returns the merged template and the effective patch, or an error
+// when the overrides are malformed or change a selector label or a
+// controller annotation.
+func mergePodTemplate(template *corev1.PodTemplateSpec, overrides []byte) (*corev1.PodTemplateSpec, []byte, error) {
+	original, err := json.Marshal(template)
+	if err != nil {
+		return nil, nil, err
+	}
+	mergedJSON, err := strategicpatch.StrategicMergePatch(original, overrides, corev1.PodTemplateSpec{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("spec.podTemplateOverrides: %v", err)
+	}
+	merged := &corev1.PodTemplateSpec{}
+	if err := json.Unmarshal(mergedJSON, merged); err != nil {
+		return nil, nil, fmt.Errorf("spec.podTemplateOverrides: %v", err)
+	}
+
+	for key, value := range template.Labels {
+		if merged.Labels[key] != value {
+			return nil, nil, fmt.Errorf("spec.podTemplateOverrides: label %q is used by the selector and cannot be overridden", key)
+		}
+	}
+	for key, value := range merged.Annotations {
+		if strings.HasPrefix(key, controllerAnnotationPrefix) && template.Annotations[key] != value {
+			return nil, nil, fmt.Errorf("spec.podTemplateOverrides: annotation %q is owned by the controller", key)
+		}
+	}
+	for key := range template.Annotations {
+		if _, ok := merged.Annotations[key]; !ok && strings.HasPrefix(key, controllerAnnotationPrefix) {
+			return nil, nil, fmt.Errorf("spec.podTemplateOverrides: annotation %q is owned by the controller", key)
+		}
+	}
+
+	// Round trip the merged template, so the patch only holds what the
+	// overrides changed
+	mergedJSON, err = json.Marshal(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	patch, err := strategicpatch.CreateTwoWayMergePatch(original, mergedJSON, corev1.PodTemplateSpec{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return merged, patch, nil
+}
+
+// applyPodTemplateOverrides merges spec.podTemplateOverrides into the pod
+// template of deployment and records the effective patch. The template is
+// left as generated when the overrides cannot be applied.
+func applyPodTemplateOverrides(deployment *appsv1.Deployment, website *myv1alpha1.Website) error {
+	overrides := website.Spec.PodTemplateOverrides
+	if overrides == nil || len(overrides.Raw) == 0 {
+		return nil
+	}
+	merged, patch, err := mergePodTemplate(&deployment.Spec.Template, overrides.Raw)
+	if err != nil {
+		return err
+	}
+	deployment.Spec.Template = *merged
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[overridesHashAnnotation] = hashJSON(json.RawMessage(patch))
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[overridesAnnotation] = string(patch)
+	return nil
+}
+
+// validatePodTemplateOverrides returns an error when spec.podTemplateOverrides
+// cannot be merged into the pod template generated for website.
+func validatePodTemplateOverrides(website *myv1alpha1.Website) error {
+	if website.Spec.PodTemplateOverrides == nil {
+		return nil
+	}
+	generated := website.DeepCopy()
+	generated.Spec.PodTemplateOverrides = nil
+	return applyPodTemplateOverrides(newDeployment(generated, website.Status.Revision), website)
+}
+
+// recordPodTemplateOverrides fires an event quoting the effective patch of
+// the overrides when they change, current being nil for a new Deployment.
+func (c *Controller) recordPodTemplateOverrides(owner ownerObject, desired, current *appsv1.Deployment) {
+	patch := desired.Annotations[overridesAnnotation]
+	if patch == "" || (current != nil && current.Annotations[overridesAnnotation] == patch) {
+		return
+	}
+	if len(patch) > overridesEventBytes {
+		patch = patch[:overridesEventBytes] + "..."
+	}
+	c.recorder.Eventf(owner, corev1.EventTypeNormal, PodTemplateOverridden, MessagePodTemplateOverridden, desired.Name, patch)
+}
+
+// renderWebsite writes the Deployment the controller would create for the
+// Website manifest in file, to preview spec.podTemplateOverrides without a
+// cluster. Secrets are not looked up, so their hash is missing.
+func renderWebsite(file string, out io.Writer) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	website := &myv1alpha1.Website{}
+	if err := yaml.UnmarshalStrict(data, website); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	if err := validateWebsite(website); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+
+	deployment := newDeployment(website, website.Status.Revision)
+	deployment.TypeMeta.APIVersion = appsv1.SchemeGroupVersion.String()
+	deployment.TypeMeta.Kind = "Deployment"
+	rendered, err := yaml.Marshal(deployment)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(rendered)
+	return err
+}
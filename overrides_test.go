@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+func TestMergePodTemplate(t *testing.T) {
+	website := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia"},
+		Spec: myv1alpha1.WebsiteSpec{
+			DeploymentName: "kubia",
+			GitRepo:        "https://github.com/nevermosby/kubia-website-example.git",
+		},
+	}
+	template := newDeployment(website, "0123456789abcdef0123456789abcdef01234567").Spec.Template
+	container := template.Spec.Containers[0]
+
+	tests := []struct {
+		name      string
+		overrides string
+		wantPatch string
+		wantErr   string
+	}{
+		{
+			name:      "add a label",
+			overrides: `{"metadata":{"labels":{"team":"web"}}}`,
+			wantPatch: `{"metadata":{"labels":{"team":"web"}}}`,
+		},
+		{
+			name:      "add an annotation",
+			overrides: `{"metadata":{"annotations":{"prometheus.io/scrape":"true"}}}`,
+			wantPatch: `{"metadata":{"annotations":{"prometheus.io/scrape":"true"}}}`,
+		},
+		{
+			name:      "unchanged image",
+			overrides: `{"spec":{"containers":[{"name":"` + container.Name + `","image":"` + container.Image + `"}]}}`,
+			wantPatch: `{}`,
+		},
+		{
+			name:      "change one of several fields",
+			overrides: `{"spec":{"terminationGracePeriodSeconds":5,"containers":[{"name":"` + container.Name + `","image":"` + container.Image + `"}]}}`,
+			wantPatch: `{"spec":{"terminationGracePeriodSeconds":5}}`,
+		},
+		{
+			name:      "change a selector label",
+			overrides: `{"metadata":{"labels":{"app":"other"}}}`,
+			wantErr:   `label "app" is used by the selector`,
+		},
+		{
+			name:      "delete a selector label",
+			overrides: `{"metadata":{"labels":{"controller":null}}}`,
+			wantErr:   `label "controller" is used by the selector`,
+		},
+		{
+			name:      "add a controller annotation",
+			overrides: `{"metadata":{"annotations":{"mycontroller.nevermosby.io/build":"x"}}}`,
+			wantErr:   `annotation "mycontroller.nevermosby.io/build" is owned by the controller`,
+		},
+		{
+			name:      "change a controller annotation",
+			overrides: `{"metadata":{"annotations":{"mycontroller.nevermosby.io/revision":"master"}}}`,
+			wantErr:   `annotation "mycontroller.nevermosby.io/revision" is owned by the controller`,
+		},
+		{
+			name:      "delete a controller annotation",
+			overrides: `{"metadata":{"annotations":{"mycontroller.nevermosby.io/revision":null}}}`,
+			wantErr:   `annotation "mycontroller.nevermosby.io/revision" is owned by the controller`,
+		},
+		{
+			name:      "malformed",
+			overrides: `{"spec":`,
+			wantErr:   "spec.podTemplateOverrides",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, patch, err := mergePodTemplate(template.DeepCopy(), []byte(tt.overrides))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("mergePodTemplate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergePodTemplate() error = %v", err)
+			}
+			if string(patch) != tt.wantPatch {
+				t.Errorf("effective patch = %s, want %s", patch, tt.wantPatch)
+			}
+		})
+	}
+}
+
+func TestRenderWebsite(t *testing.T) {
+	const website = `apiVersion: mycontroller.nevermosby.io/v1alpha1
+kind: Website
+metadata:
+  name: kubia
+spec:
+  deploymentName: kubia-website
+  gitRepo: https://github.com/nevermosby/kubia-website-example.git
+`
+	tests := []struct {
+		name     string
+		manifest string
+		want     []string
+		wantErr  string
+	}{
+		{
+			name:     "without overrides",
+			manifest: website,
+			want:     []string{"kind: Deployment", "name: kubia-website"},
+		},
+		{
+			name: "overrides",
+			manifest: website + `  podTemplateOverrides:
+    spec:
+      terminationGracePeriodSeconds: 5
+`,
+			want: []string{
+				"terminationGracePeriodSeconds: 5",
+				overridesAnnotation + `: '{"spec":{"terminationGracePeriodSeconds":5}}'`,
+				overridesHashAnnotation + ":",
+			},
+		},
+		{
+			name: "selector label override",
+			manifest: website + `  podTemplateOverrides:
+    metadata:
+      labels:
+        app: other
+`,
+			wantErr: `label "app" is used by the selector`,
+		},
+		{
+			name: "controller annotation override",
+			manifest: website + `  podTemplateOverrides:
+    metadata:
+      annotations:
+        mycontroller.nevermosby.io/revision: master
+`,
+			wantErr: `annotation "mycontroller.nevermosby.io/revision" is owned by the controller`,
+		},
+		{
+			name:     "unknown field",
+			manifest: website + "  replica: 2\n",
+			wantErr:  `unknown field "replica"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+			file := filepath.Join(dir, "website.yaml")
+			if err := ioutil.WriteFile(file, []byte(tt.manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			err := renderWebsite(file, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("renderWebsite() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderWebsite() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("rendered Deployment lacks %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
@@ -35,6 +35,16 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Website{},
 		&WebsiteList{},
+		&WebsitePreview{},
+		&WebsitePreviewList{},
+		&WebsitePolicy{},
+		&WebsitePolicyList{},
+		&ClusterWebsitePolicy{},
+		&ClusterWebsitePolicyList{},
+		&WebsiteTemplate{},
+		&WebsiteTemplateList{},
+		&ClusterWebsite{},
+		&ClusterWebsiteList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -1,7 +1,12 @@
 package v1alpha1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +genclient
@@ -16,9 +21,82 @@ type Website struct {
 }
 
 type WebsiteSpec struct {
-	GitRepo        string `json:"gitRepo"`
+	// GitRepo and Branch are shorthand for a git source, used when Source
+	// is unset.
+	GitRepo string `json:"gitRepo,omitempty"`
+	// Branch of GitRepo to serve, defaults to master.
+	Branch string `json:"branch,omitempty"`
+	// Source is where the site content comes from, when it is not a git
+	// repository.
+	Source *WebsiteSource `json:"source,omitempty"`
+	// Sources composes the site from several sources, each served at its
+	// mountPath. The first one is the primary source mounted at /: revisions,
+	// rollbacks, contentPath and the build apply to it.
+	Sources []WebsiteSource `json:"sources,omitempty"`
+	// ContentPath is the directory of the repository holding the site,
+	// e.g. docs. Defaults to the whole repository.
+	ContentPath    string `json:"contentPath,omitempty"`
 	DeploymentName string `json:"deploymentName"`
 	Replicas       *int32 `json:"replicas"`
+	// RollbackTo pins the Website to a revision from status.history instead
+	// of following the branch. Clearing it resumes following the branch.
+	RollbackTo string `json:"rollbackTo,omitempty"`
+	// Ingress exposes the Website on a host through an Ingress.
+	Ingress *WebsiteIngress `json:"ingress,omitempty"`
+	// Template names the WebsiteTemplate whose defaults the Website
+	// inherits.
+	Template string `json:"template,omitempty"`
+	// ServiceType is the type of the website Service, NodePort when unset.
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+	// Strategy controls how new revisions are rolled out, defaults to a
+	// rolling update of the Deployment.
+	Strategy *WebsiteStrategy `json:"strategy,omitempty"`
+	// Build turns the checkout into the served site before the pods serve
+	// it, e.g. with Hugo, Jekyll or npm. Without it the checkout is served
+	// as is.
+	Build *WebsiteBuild `json:"build,omitempty"`
+	// Server configures how the site is served. Without it the stock nginx
+	// configuration is used.
+	Server *WebsiteServer `json:"server,omitempty"`
+	// Auth protects the site with HTTP basic auth.
+	Auth *WebsiteAuth `json:"auth,omitempty"`
+	// Resources of the website pods, defaults come from the controller
+	// flags.
+	Resources *WebsiteResources `json:"resources,omitempty"`
+
+	// NodeSelector, Tolerations, Affinity, TopologySpreadConstraints,
+	// PriorityClassName and ServiceAccountName are set on the website pods.
+	NodeSelector              map[string]string                 `json:"nodeSelector,omitempty"`
+	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty"`
+	Affinity                  *corev1.Affinity                  `json:"affinity,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	PriorityClassName         string                            `json:"priorityClassName,omitempty"`
+	ServiceAccountName        string                            `json:"serviceAccountName,omitempty"`
+	// AntiAffinity spreads the replicas across nodes unless spec.affinity
+	// sets a pod anti-affinity. Off by default.
+	AntiAffinity AntiAffinityMode `json:"antiAffinity,omitempty"`
+	// PodSecurity picks how locked down the pods run, defaults to the
+	// controller flag -default-pod-security.
+	PodSecurity PodSecurityMode `json:"podSecurity,omitempty"`
+	// NetworkPolicy restricts the traffic of the website pods with a
+	// NetworkPolicy owned by the Website. None is created when unset.
+	NetworkPolicy *WebsiteNetworkPolicy `json:"networkPolicy,omitempty"`
+	// Availability bounds the voluntary disruptions of the website pods,
+	// e.g. node drains, with a PodDisruptionBudget owned by the Website.
+	Availability *WebsiteAvailability `json:"availability,omitempty"`
+	// Deployment tunes how the website Deployments roll out.
+	Deployment *WebsiteDeployment `json:"deployment,omitempty"`
+
+	// PodTemplateOverrides is a partial PodTemplateSpec merged into the
+	// generated pod template as a strategic merge patch, e.g. to add a
+	// sidecar, env vars or volumes. The selector labels and the controller
+	// annotations cannot be overridden.
+	PodTemplateOverrides *runtime.RawExtension `json:"podTemplateOverrides,omitempty"`
+	// AdoptExisting lets the controller take over a Deployment and Service
+	// of the names of the website that exist without a controller, e.g. of a
+	// site built by hand, and bring them to the desired state. They are
+	// refused otherwise.
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
 	// TargetDeployment string `json:"targetDeployment"`
 	// MinReplicas      int    `json:"minReplicas"`
 	// MaxReplicas      int    `json:"maxReplicas"`
@@ -28,8 +106,415 @@ type WebsiteSpec struct {
 	// ScaleDown        int    `json:"scaleDown"`
 }
 
+// WebsiteSource is a union, exactly one member must be set.
+type WebsiteSource struct {
+	// Name identifies the source in status.sources, required in
+	// spec.sources.
+	Name string `json:"name,omitempty"`
+	// MountPath is where spec.sources serves the source under the web
+	// root, e.g. /docs/v1. Defaults to /.
+	MountPath string `json:"mountPath,omitempty"`
+
+	Git *GitSource `json:"git,omitempty"`
+	// HTTP downloads a tarball or zip archive.
+	HTTP *HTTPSource `json:"http,omitempty"`
+	// OCI pulls an OCI artifact pushed with oras.
+	OCI *OCISource `json:"oci,omitempty"`
+	// ConfigMap serves the keys of a ConfigMap as files, for tiny sites.
+	ConfigMap *ConfigMapSource `json:"configMap,omitempty"`
+	// S3 mirrors a prefix of an S3 compatible bucket.
+	S3 *S3Source `json:"s3,omitempty"`
+}
+
+type GitSource struct {
+	Repo string `json:"repo"`
+	// Branch to serve, defaults to master.
+	Branch string `json:"branch,omitempty"`
+}
+
+type HTTPSource struct {
+	// URL of a .tar, .tar.gz, .tgz or .zip archive.
+	URL string `json:"url"`
+	// SHA256 is the hex encoded checksum of the archive.
+	SHA256 string `json:"sha256"`
+}
+
+type OCISource struct {
+	// Reference of the artifact, e.g. ghcr.io/org/site:v1.
+	Reference string `json:"reference"`
+	// PlainHTTP talks to the registry without TLS, e.g. to a local one.
+	PlainHTTP bool `json:"plainHTTP,omitempty"`
+}
+
+type ConfigMapSource struct {
+	// Name of the ConfigMap in the Website namespace.
+	Name string `json:"name"`
+}
+
+type S3Source struct {
+	// Endpoint is the URL of the S3 API, e.g. https://s3.amazonaws.com or
+	// http://minio:9000.
+	Endpoint string `json:"endpoint"`
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix,omitempty"`
+	// SecretName is a Secret with the AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY keys, the bucket is read anonymously when
+	// unset.
+	SecretName string `json:"secretName,omitempty"`
+}
+
+type WebsiteIngress struct {
+	// Host is the hostname the Website is served on.
+	Host string `json:"host"`
+	// Annotations are added to the Ingress, e.g. kubernetes.io/ingress.class.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type WebsiteNetworkPolicy struct {
+	// AllowedNamespaces select the namespaces whose pods may reach the
+	// website pods, besides the ingress controller when spec.ingress is
+	// set.
+	AllowedNamespaces []metav1.LabelSelector `json:"allowedNamespaces,omitempty"`
+	// EgressCIDRs are the addresses of the source hosts, e.g. of the git
+	// host. The pods may connect to any address on the ports of their
+	// sources when empty, e.g. to any HTTPS host for an https:// source.
+	EgressCIDRs []string `json:"egressCIDRs,omitempty"`
+}
+
+type WebsiteAvailability struct {
+	// MinAvailable and MaxUnavailable are the ones of the
+	// PodDisruptionBudget, exactly one of them must be set.
+	MinAvailable   *intstr.IntOrString `json:"minAvailable,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+type WebsiteDeployment struct {
+	// Strategy replaces the pods by a rolling update or recreates them.
+	Strategy *appsv1.DeploymentStrategy `json:"strategy,omitempty"`
+	// MinReadySeconds, ProgressDeadlineSeconds and RevisionHistoryLimit
+	// are set on the Deployments, with the Kubernetes defaults when unset.
+	MinReadySeconds         int32  `json:"minReadySeconds,omitempty"`
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+	RevisionHistoryLimit    *int32 `json:"revisionHistoryLimit,omitempty"`
+}
+
+type WebsiteBuild struct {
+	// Image is the builder image, e.g. klakegg/hugo.
+	Image string `json:"image"`
+	// Command is run in spec.contentPath of the checkout, the image
+	// entrypoint when empty.
+	Command []string `json:"command,omitempty"`
+	// OutputDir is the directory the build writes the site to, relative to
+	// spec.contentPath, e.g. public.
+	OutputDir string          `json:"outputDir"`
+	Env       []corev1.EnvVar `json:"env,omitempty"`
+}
+
+type WebsiteServer struct {
+	// Type is the web server serving the site: nginx, caddy or httpd,
+	// defaults to nginx.
+	Type ServerType `json:"type,omitempty"`
+	// Redirects are applied in order before files are looked up.
+	Redirects []WebsiteRedirect `json:"redirects,omitempty"`
+	// Headers adds response headers below path prefixes, e.g. a CSP for the
+	// whole site and cache-control for /assets.
+	Headers []WebsiteHeaders `json:"headers,omitempty"`
+	// NotFoundPage is served for missing files, e.g. /404.html.
+	NotFoundPage string `json:"notFoundPage,omitempty"`
+	// SPAFallback serves /index.html for paths matching no file, as single
+	// page applications route on the client.
+	SPAFallback      bool `json:"spaFallback,omitempty"`
+	Gzip             bool `json:"gzip,omitempty"`
+	DirectoryListing bool `json:"directoryListing,omitempty"`
+}
+
+type ServerType string
+
+const (
+	ServerTypeNginx ServerType = "nginx"
+	ServerTypeCaddy ServerType = "caddy"
+	// ServerTypeHTTPD is the Apache HTTP Server.
+	ServerTypeHTTPD ServerType = "httpd"
+)
+
+type AntiAffinityMode string
+
+const (
+	// AntiAffinityPreferred schedules replicas on different nodes when
+	// possible.
+	AntiAffinityPreferred AntiAffinityMode = "Preferred"
+	// AntiAffinityRequired never schedules two replicas on the same node.
+	AntiAffinityRequired AntiAffinityMode = "Required"
+)
+
+type PodSecurityMode string
+
+const (
+	// PodSecurityHardened runs the pods as an unprivileged user with a
+	// read-only root filesystem, no capabilities and the default seccomp
+	// profile of the runtime. The server listens on 8080.
+	PodSecurityHardened PodSecurityMode = "Hardened"
+	// PodSecurityUnrestricted runs the stock images as they are.
+	PodSecurityUnrestricted PodSecurityMode = "Unrestricted"
+)
+
+type WebsiteResources struct {
+	// Server are the resources of the serving container.
+	Server *corev1.ResourceRequirements `json:"server,omitempty"`
+	// Sync are the resources of each container fetching or syncing a
+	// source, and of the build helpers.
+	Sync *corev1.ResourceRequirements `json:"sync,omitempty"`
+	// Build are the resources of the build container, defaults to Sync.
+	Build *corev1.ResourceRequirements `json:"build,omitempty"`
+	// ContentSizeLimit bounds each volume holding content, e.g. 1Gi.
+	ContentSizeLimit *resource.Quantity `json:"contentSizeLimit,omitempty"`
+}
+
+type WebsiteAuth struct {
+	// SecretName is the Secret in the Website namespace holding the
+	// htpasswd file.
+	SecretName string `json:"secretName"`
+	// Key is the key of the htpasswd file in the Secret, defaults to auth.
+	Key string `json:"key,omitempty"`
+	// Realm is shown by browsers when asking for credentials.
+	Realm string `json:"realm,omitempty"`
+	// Paths restricts auth to these path prefixes, the whole site is
+	// protected when empty.
+	Paths []string `json:"paths,omitempty"`
+}
+
+type WebsiteRedirect struct {
+	// From is a regular expression matched against the request path.
+	From string `json:"from"`
+	// To is the target, which may refer to groups of From as $1.
+	To string `json:"to"`
+	// Code is 301 (default) or 302.
+	Code int32 `json:"code,omitempty"`
+	// Rewrite serves To in place instead of redirecting the client.
+	Rewrite bool `json:"rewrite,omitempty"`
+}
+
+type WebsiteHeaders struct {
+	// Path is the path prefix the headers apply to, defaults to /.
+	Path    string            `json:"path,omitempty"`
+	Headers map[string]string `json:"headers"`
+}
+
+type WebsiteStrategyType string
+
+const (
+	// RollingWebsiteStrategy rolls new revisions out with a rolling update of
+	// the Deployment.
+	RollingWebsiteStrategy WebsiteStrategyType = "Rolling"
+	// CanaryWebsiteStrategy exposes new revisions to a share of the traffic
+	// first, see CanaryStrategy.
+	CanaryWebsiteStrategy WebsiteStrategyType = "Canary"
+	// BlueGreenWebsiteStrategy brings new revisions up next to the current
+	// one and switches all traffic at once, see BlueGreenStrategy.
+	BlueGreenWebsiteStrategy WebsiteStrategyType = "BlueGreen"
+)
+
+type WebsiteStrategy struct {
+	Type      WebsiteStrategyType `json:"type,omitempty"`
+	Canary    *CanaryStrategy     `json:"canary,omitempty"`
+	BlueGreen *BlueGreenStrategy  `json:"blueGreen,omitempty"`
+}
+
+type CanaryTrafficRouting string
+
+const (
+	// ReplicasCanaryRouting splits the traffic of the Website Service by the
+	// ratio of stable and canary replicas.
+	ReplicasCanaryRouting CanaryTrafficRouting = "Replicas"
+	// IngressCanaryRouting splits the traffic with a weighted canary Ingress
+	// (ingress-nginx canary annotations). Requires spec.ingress.
+	IngressCanaryRouting CanaryTrafficRouting = "Ingress"
+)
+
+type CanaryStrategy struct {
+	// TrafficRouting selects how traffic is split, defaults to Replicas.
+	TrafficRouting CanaryTrafficRouting `json:"trafficRouting,omitempty"`
+	// Steps are gone through in order once the canary is ready. The canary
+	// is promoted after the last step.
+	Steps []CanaryStep `json:"steps"`
+}
+
+type CanaryStep struct {
+	// Weight is the percentage of the traffic sent to the new revision.
+	Weight int32 `json:"weight"`
+	// Pause is how long to stay at Weight before the next step.
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
+type BlueGreenStrategy struct {
+	// ScaleDownDelay is how long the previous color keeps running after a
+	// switch, so the switch can be reverted instantly. Defaults to 10m.
+	ScaleDownDelay *metav1.Duration `json:"scaleDownDelay,omitempty"`
+}
+
 type WebsiteStatus struct {
 	AvailableReplicas int32 `json:"availableReplicas"`
+	// Revision is the commit SHA the site pods are pinned to.
+	Revision string `json:"revision,omitempty"`
+	// LatestRevision is the commit SHA the followed branch resolved to.
+	LatestRevision string `json:"latestRevision,omitempty"`
+	// ResolvedRef is the repository and branch LatestRevision was resolved
+	// from, as gitRepo#branch.
+	ResolvedRef string `json:"resolvedRef,omitempty"`
+	// LastResolveTime is when the branch was last resolved with ls-remote.
+	LastResolveTime *metav1.Time `json:"lastResolveTime,omitempty"`
+	// History lists the revisions rolled out, most recent first.
+	History []WebsiteRevision `json:"history,omitempty"`
+	// Canary reports the progress of the last canary release.
+	Canary *CanaryStatus `json:"canary,omitempty"`
+	// BlueGreen reports the colors of a blue/green Website.
+	BlueGreen *BlueGreenStatus `json:"blueGreen,omitempty"`
+	// Build reports the build of the latest revision.
+	Build *BuildStatus `json:"build,omitempty"`
+	// Sources reports each of spec.sources.
+	Sources    []SourceStatus     `json:"sources,omitempty"`
+	Conditions []WebsiteCondition `json:"conditions,omitempty"`
+}
+
+type SourcePhase string
+
+const (
+	// SourceSyncing means no website pod fetched the source yet.
+	SourceSyncing SourcePhase = "Syncing"
+	// SourceSynced means a website pod fetched the source.
+	SourceSynced SourcePhase = "Synced"
+	// SourceFailed means fetching the source failed.
+	SourceFailed SourcePhase = "Failed"
+)
+
+type SourceStatus struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	// Type is the member set in the source: git, http, oci, configMap or
+	// s3.
+	Type string `json:"type"`
+	// Revision identifies the served content: the commit of a git source,
+	// the checksum of an archive, the reference of an artifact or the
+	// resource version of a ConfigMap.
+	Revision string `json:"revision,omitempty"`
+	// ResolvedRef is the repository and branch of a git source Revision
+	// was resolved from, as repo#branch.
+	ResolvedRef string `json:"resolvedRef,omitempty"`
+	// LastResolveTime is when the branch of a git source was last resolved.
+	LastResolveTime *metav1.Time `json:"lastResolveTime,omitempty"`
+	Phase           SourcePhase  `json:"phase"`
+	Message         string       `json:"message,omitempty"`
+}
+
+type BuildPhase string
+
+const (
+	// BuildRunning means the pods of the revision are still building it.
+	BuildRunning BuildPhase = "Running"
+	// BuildSucceeded means a pod built the revision and serves it.
+	BuildSucceeded BuildPhase = "Succeeded"
+	// BuildFailed means the build command failed. The pods of the previous
+	// revision keep serving until a build succeeds.
+	BuildFailed BuildPhase = "Failed"
+)
+
+type BuildStatus struct {
+	// Revision is the commit being built.
+	Revision string     `json:"revision"`
+	Phase    BuildPhase `json:"phase"`
+	Message  string     `json:"message,omitempty"`
+	// Log is the tail of the log of a failed build.
+	Log string `json:"log,omitempty"`
+}
+
+type CanaryPhase string
+
+const (
+	// CanaryProgressing means the canary is rolling out or waiting for its
+	// pods to become ready.
+	CanaryProgressing CanaryPhase = "Progressing"
+	// CanaryPaused means the canary is ready and serving Weight percent of
+	// the traffic for the pause of the current step.
+	CanaryPaused CanaryPhase = "Paused"
+	// CanaryPromoting means all steps passed and the stable Deployment is
+	// rolling out the canary revision.
+	CanaryPromoting CanaryPhase = "Promoting"
+	// CanaryPromoted means the canary revision became the stable one.
+	CanaryPromoted CanaryPhase = "Promoted"
+	// CanaryAborted means the canary did not become ready and was removed.
+	// The revision is not tried again until the branch moves on.
+	CanaryAborted CanaryPhase = "Aborted"
+)
+
+type CanaryStatus struct {
+	// Revision is the commit under test.
+	Revision string      `json:"revision"`
+	Phase    CanaryPhase `json:"phase"`
+	// Step is the index of the current step in spec.strategy.canary.steps.
+	Step   int32 `json:"step"`
+	Weight int32 `json:"weight"`
+	// StepStartTime is when the canary became ready at the current step.
+	StepStartTime *metav1.Time `json:"stepStartTime,omitempty"`
+	Message       string       `json:"message,omitempty"`
+}
+
+type BlueGreenStatus struct {
+	// ActiveColor is the color the Website Service selects, blue or green.
+	ActiveColor string `json:"activeColor,omitempty"`
+	// IdleRevision is the revision of the other color, empty once it is
+	// scaled down.
+	IdleRevision string `json:"idleRevision,omitempty"`
+	// SwitchTime is when the Service was last switched to ActiveColor.
+	SwitchTime *metav1.Time `json:"switchTime,omitempty"`
+	Message    string       `json:"message,omitempty"`
+}
+
+// WebsiteRevision is an entry of the revision history of a Website.
+type WebsiteRevision struct {
+	// Revision is the commit SHA that was rolled out.
+	Revision string `json:"revision"`
+	// Time is when the revision was rolled out.
+	Time metav1.Time `json:"time"`
+	// TriggeredBy tells what rolled the revision out: poll, webhook or
+	// rollback.
+	TriggeredBy string `json:"triggeredBy,omitempty"`
+}
+
+type WebsiteConditionType string
+
+const (
+	// WebsiteRevisionResolved is true when the followed branch was resolved
+	// to a commit on the last attempt.
+	WebsiteRevisionResolved WebsiteConditionType = "RevisionResolved"
+	// WebsiteRolledBack is true while spec.rollbackTo pins the Website to a
+	// revision from its history.
+	WebsiteRolledBack WebsiteConditionType = "RolledBack"
+	// WebsiteContentPathFound tells whether spec.contentPath exists in the
+	// latest revision.
+	WebsiteContentPathFound WebsiteConditionType = "ContentPathFound"
+	// WebsitePodsEvicted is true while website pods were evicted recently,
+	// e.g. for exceeding the content size limit.
+	WebsitePodsEvicted WebsiteConditionType = "PodsEvicted"
+	// WebsiteTemplateResolved is false while the WebsiteTemplate named in
+	// spec.template cannot be resolved and the website is not synced.
+	WebsiteTemplateResolved WebsiteConditionType = "TemplateResolved"
+	// WebsitePolicyViolated is true while the website violates a
+	// WebsitePolicy or ClusterWebsitePolicy and is not synced.
+	WebsitePolicyViolated WebsiteConditionType = "PolicyViolated"
+	// WebsiteConflict is true while the website shares its deployment,
+	// service or host with an older website and is not synced.
+	WebsiteConflict WebsiteConditionType = "Conflict"
+	// WebsiteOOMKilled is true while containers of the website pods were
+	// killed for exceeding their memory limit recently.
+	WebsiteOOMKilled WebsiteConditionType = "OOMKilled"
+)
+
+type WebsiteCondition struct {
+	Type               WebsiteConditionType   `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -40,3 +525,188 @@ type WebsiteList struct {
 
 	Items []Website `json:"items"`
 }
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WebsitePreview serves a branch of a Website's repository next to it,
+// with a hostname derived from the branch.
+type WebsitePreview struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WebsitePreviewSpec   `json:"spec"`
+	Status WebsitePreviewStatus `json:"status"`
+}
+
+type WebsitePreviewSpec struct {
+	// Website is the name of the parent Website in the same namespace.
+	Website string `json:"website"`
+	// Branch of the parent's GitRepo to preview.
+	Branch string `json:"branch"`
+	// Host overrides the hostname derived from the branch and the parent's
+	// ingress host.
+	Host string `json:"host,omitempty"`
+	// TTL deletes the preview once it elapsed since its creation.
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+type WebsitePreviewStatus struct {
+	AvailableReplicas int32 `json:"availableReplicas"`
+	// Revision is the commit SHA of Branch the preview serves.
+	Revision string `json:"revision,omitempty"`
+	// ResolvedRef is the repository and branch Revision was resolved from,
+	// as gitRepo#branch.
+	ResolvedRef string `json:"resolvedRef,omitempty"`
+	// LastResolveTime is when the branch was last resolved with ls-remote.
+	LastResolveTime *metav1.Time `json:"lastResolveTime,omitempty"`
+	// URL is where the preview can be reached.
+	URL string `json:"url,omitempty"`
+	// ExpirationTime is when the preview is deleted, if it has a TTL.
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+	Message        string       `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type WebsitePreviewList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []WebsitePreview `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WebsitePolicy limits what the Websites of its namespace may do. Every
+// policy of a namespace applies, a Website violating one is not synced.
+type WebsitePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WebsitePolicySpec `json:"spec"`
+}
+
+type WebsitePolicySpec struct {
+	// AllowedGitHosts are the hosts git sources may be cloned from, e.g.
+	// github.com. Any host when empty.
+	AllowedGitHosts []string `json:"allowedGitHosts,omitempty"`
+	// AllowedURLPatterns are regular expressions one of which every source
+	// location must match: git repositories, archive URLs, OCI references
+	// and S3 endpoints. Any location when empty.
+	AllowedURLPatterns []string `json:"allowedURLPatterns,omitempty"`
+	// MaxReplicas bounds spec.replicas of each Website.
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+	// MaxNamespaceReplicas bounds the replicas of all Websites of the
+	// namespace together. The oldest Websites get theirs first.
+	MaxNamespaceReplicas *int32 `json:"maxNamespaceReplicas,omitempty"`
+	// AllowedServiceTypes are the spec.serviceType values Websites may use.
+	// Any type when empty.
+	AllowedServiceTypes []corev1.ServiceType `json:"allowedServiceTypes,omitempty"`
+	// RequiredLabels are label keys every Website must carry, e.g. team.
+	RequiredLabels []string `json:"requiredLabels,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type WebsitePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []WebsitePolicy `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterWebsitePolicy is a WebsitePolicy applying to the namespaces its
+// selector matches, set by the platform team for all tenants.
+type ClusterWebsitePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterWebsitePolicySpec `json:"spec"`
+}
+
+type ClusterWebsitePolicySpec struct {
+	// NamespaceSelector selects the namespaces the policy applies to, all
+	// of them when unset.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	WebsitePolicySpec `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ClusterWebsitePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterWebsitePolicy `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WebsiteTemplate holds organization-wide defaults of the Website spec,
+// inherited by the Websites naming it in spec.template.
+type WebsiteTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WebsiteTemplateSpec `json:"spec"`
+}
+
+type WebsiteTemplateSpec struct {
+	// Defaults are the spec fields the Websites inherit. A field the
+	// Website sets wins, objects such as resources or server are merged
+	// field by field and lists are replaced. deploymentName and template
+	// are not inherited.
+	Defaults WebsiteSpec `json:"defaults"`
+	// Enforced lists fields of defaults the Websites cannot override, by
+	// their name in the spec, e.g. podSecurity.
+	Enforced []string `json:"enforced,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type WebsiteTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []WebsiteTemplate `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterWebsite is a Website owned by the platform team, e.g. a status
+// page or shared docs, deployed into a designated namespace.
+type ClusterWebsite struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterWebsiteSpec `json:"spec"`
+	Status WebsiteStatus      `json:"status"`
+}
+
+type ClusterWebsiteSpec struct {
+	// TargetNamespace is the namespace the Deployment, Service and other
+	// children of the ClusterWebsite are created in.
+	TargetNamespace string `json:"targetNamespace"`
+
+	WebsiteSpec `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ClusterWebsiteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterWebsite `json:"items"`
+}
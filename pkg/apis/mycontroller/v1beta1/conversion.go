@@ -0,0 +1,181 @@
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/conversion"
+
+	"github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// SourceFormAnnotation records how a v1alpha1 Website named its sources,
+// when it is not the form they convert back to by default, so objects
+// written as v1alpha1 read back the same.
+const SourceFormAnnotation = "mycontroller.nevermosby.io/v1alpha1-source-form"
+
+// The forms of v1alpha1 sources.
+const (
+	sourceFormGitRepo = "gitRepo"
+	sourceFormSource  = "source"
+	sourceFormSources = "sources"
+)
+
+// Convert_v1alpha1_Website_To_v1beta1_Website converts a v1alpha1 Website.
+// Only the source of gitRepo, source and sources the controller uses is
+// kept, the others are ignored by v1alpha1 as well. The signature is the
+// one of conversion-gen, s may be nil.
+func Convert_v1alpha1_Website_To_v1beta1_Website(in *v1alpha1.Website, out *Website, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	out.TypeMeta.APIVersion = SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+
+	spec := in.Spec.DeepCopy()
+	var form string
+	var sources []v1alpha1.WebsiteSource
+	switch {
+	case len(spec.Sources) > 0:
+		form, sources = sourceFormSources, spec.Sources
+	case spec.Source != nil:
+		form, sources = sourceFormSource, []v1alpha1.WebsiteSource{*spec.Source}
+	case spec.GitRepo != "" || spec.Branch != "":
+		form = sourceFormGitRepo
+		sources = []v1alpha1.WebsiteSource{{Git: &v1alpha1.GitSource{Repo: spec.GitRepo, Branch: spec.Branch}}}
+	}
+	if form != "" && form != defaultSourceForm(sources) {
+		if out.Annotations == nil {
+			out.Annotations = map[string]string{}
+		}
+		out.Annotations[SourceFormAnnotation] = form
+	}
+
+	out.Spec = WebsiteSpec{
+		DeploymentName: spec.DeploymentName,
+		Template:       spec.Template,
+		Source: WebsiteSourceSpec{
+			Sources:     sources,
+			ContentPath: spec.ContentPath,
+			Build:       spec.Build,
+		},
+		Service: WebsiteServiceSpec{
+			Type:    spec.ServiceType,
+			Ingress: spec.Ingress,
+		},
+		Server: WebsiteServerSpec{
+			Config: spec.Server,
+			Auth:   spec.Auth,
+		},
+		Scaling: WebsiteScaling{
+			Replicas:     spec.Replicas,
+			Availability: spec.Availability,
+		},
+		RollbackTo:                spec.RollbackTo,
+		Strategy:                  spec.Strategy,
+		Resources:                 spec.Resources,
+		NodeSelector:              spec.NodeSelector,
+		Tolerations:               spec.Tolerations,
+		Affinity:                  spec.Affinity,
+		TopologySpreadConstraints: spec.TopologySpreadConstraints,
+		PriorityClassName:         spec.PriorityClassName,
+		ServiceAccountName:        spec.ServiceAccountName,
+		AntiAffinity:              spec.AntiAffinity,
+		PodSecurity:               spec.PodSecurity,
+		NetworkPolicy:             spec.NetworkPolicy,
+		Deployment:                spec.Deployment,
+		PodTemplateOverrides:      spec.PodTemplateOverrides,
+		AdoptExisting:             spec.AdoptExisting,
+	}
+	return nil
+}
+
+// Convert_v1beta1_Website_To_v1alpha1_Website converts a v1beta1 Website,
+// naming its sources in the form SourceFormAnnotation records when they
+// fit it.
+func Convert_v1beta1_Website_To_v1alpha1_Website(in *Website, out *v1alpha1.Website, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	out.TypeMeta.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+
+	spec := in.Spec.DeepCopy()
+	sources := spec.Source.Sources
+	form := defaultSourceForm(sources)
+	if recorded, ok := out.Annotations[SourceFormAnnotation]; ok {
+		if sourceFormFits(recorded, sources) {
+			form = recorded
+		}
+		delete(out.Annotations, SourceFormAnnotation)
+		if len(out.Annotations) == 0 {
+			out.Annotations = nil
+		}
+	}
+
+	out.Spec = v1alpha1.WebsiteSpec{
+		ContentPath:               spec.Source.ContentPath,
+		DeploymentName:            spec.DeploymentName,
+		Replicas:                  spec.Scaling.Replicas,
+		RollbackTo:                spec.RollbackTo,
+		Ingress:                   spec.Service.Ingress,
+		Template:                  spec.Template,
+		ServiceType:               spec.Service.Type,
+		Strategy:                  spec.Strategy,
+		Build:                     spec.Source.Build,
+		Server:                    spec.Server.Config,
+		Auth:                      spec.Server.Auth,
+		Resources:                 spec.Resources,
+		NodeSelector:              spec.NodeSelector,
+		Tolerations:               spec.Tolerations,
+		Affinity:                  spec.Affinity,
+		TopologySpreadConstraints: spec.TopologySpreadConstraints,
+		PriorityClassName:         spec.PriorityClassName,
+		ServiceAccountName:        spec.ServiceAccountName,
+		AntiAffinity:              spec.AntiAffinity,
+		PodSecurity:               spec.PodSecurity,
+		NetworkPolicy:             spec.NetworkPolicy,
+		Availability:              spec.Scaling.Availability,
+		Deployment:                spec.Deployment,
+		PodTemplateOverrides:      spec.PodTemplateOverrides,
+		AdoptExisting:             spec.AdoptExisting,
+	}
+	switch form {
+	case sourceFormGitRepo:
+		out.Spec.GitRepo = sources[0].Git.Repo
+		out.Spec.Branch = sources[0].Git.Branch
+	case sourceFormSource:
+		out.Spec.Source = &sources[0]
+	case sourceFormSources:
+		out.Spec.Sources = sources
+	}
+	return nil
+}
+
+// defaultSourceForm returns the form sources convert to without
+// SourceFormAnnotation: the gitRepo shorthand for a plain git source, source
+// for any other single source and sources for several.
+func defaultSourceForm(sources []v1alpha1.WebsiteSource) string {
+	switch {
+	case len(sources) == 0:
+		return ""
+	case sourceFormFits(sourceFormGitRepo, sources):
+		return sourceFormGitRepo
+	case len(sources) == 1:
+		return sourceFormSource
+	}
+	return sourceFormSources
+}
+
+// sourceFormFits returns whether sources can be written in form.
+func sourceFormFits(form string, sources []v1alpha1.WebsiteSource) bool {
+	switch form {
+	case sourceFormGitRepo:
+		if len(sources) != 1 {
+			return false
+		}
+		source := sources[0]
+		return source.Git != nil && source.Name == "" && source.MountPath == "" &&
+			source.HTTP == nil && source.OCI == nil && source.ConfigMap == nil && source.S3 == nil
+	case sourceFormSource:
+		return len(sources) == 1
+	case sourceFormSources:
+		return len(sources) > 0
+	}
+	return false
+}
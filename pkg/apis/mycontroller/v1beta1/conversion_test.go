@@ -0,0 +1,162 @@
+package v1beta1
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const fuzzIterations = 500
+
+// fuzzSeed seeds the fuzzers, so the round trips are reproducible. Other
+// seeds are tried with go test -args -fuzz-seed=N.
+var fuzzSeed = flag.Int64("fuzz-seed", 1, "seed of the conversion fuzzers")
+
+// newFuzzer returns a fuzzer seeded with -fuzz-seed, logging the seed so a
+// failure can be replayed.
+func newFuzzer(t *testing.T) *fuzz.Fuzzer {
+	t.Logf("seed %d", *fuzzSeed)
+	return fuzz.New().NilChance(.3).NumElements(0, 2).MaxDepth(8).RandSource(rand.NewSource(*fuzzSeed)).Funcs(
+		// spec.podTemplateOverrides is kept as raw JSON, never decoded
+		func(e *runtime.RawExtension, c fuzz.Continue) {
+			e.Raw = []byte(fmt.Sprintf(`{"metadata":{"labels":{"tier":%q}}}`, c.RandString()))
+		},
+	)
+}
+
+// fuzzSource returns a fuzzed source, with a git repository when it is a
+// git source, which validation requires.
+func fuzzSource(f *fuzz.Fuzzer) v1alpha1.WebsiteSource {
+	source := v1alpha1.WebsiteSource{}
+	f.Fuzz(&source)
+	if source.Git != nil && source.Git.Repo == "" {
+		source.Git.Repo = "https://github.com/nevermosby/kubia-website-example.git"
+	}
+	return source
+}
+
+// fuzzSources returns one or two fuzzed sources.
+func fuzzSources(f *fuzz.Fuzzer, i int) []v1alpha1.WebsiteSource {
+	sources := []v1alpha1.WebsiteSource{fuzzSource(f)}
+	if i%2 == 1 {
+		sources = append(sources, fuzzSource(f))
+	}
+	return sources
+}
+
+func TestRoundTripV1alpha1(t *testing.T) {
+	f := newFuzzer(t)
+	forms := []string{"", sourceFormGitRepo, sourceFormSource, sourceFormSources}
+	for i := 0; i < fuzzIterations; i++ {
+		in := &v1alpha1.Website{}
+		f.Fuzz(in)
+		delete(in.Annotations, SourceFormAnnotation)
+		// v1alpha1 Websites name their sources in a single form
+		in.Spec.GitRepo, in.Spec.Branch, in.Spec.Source, in.Spec.Sources = "", "", nil, nil
+		form := forms[i%len(forms)]
+		switch form {
+		case sourceFormGitRepo:
+			in.Spec.GitRepo = "https://github.com/nevermosby/kubia-website-example.git"
+			f.Fuzz(&in.Spec.Branch)
+		case sourceFormSource:
+			source := fuzzSource(f)
+			in.Spec.Source = &source
+		case sourceFormSources:
+			in.Spec.Sources = fuzzSources(f, i/len(forms))
+		}
+
+		beta := &Website{}
+		if err := Convert_v1alpha1_Website_To_v1beta1_Website(in.DeepCopy(), beta, nil); err != nil {
+			t.Fatal(err)
+		}
+		out := &v1alpha1.Website{}
+		if err := Convert_v1beta1_Website_To_v1alpha1_Website(beta.DeepCopy(), out, nil); err != nil {
+			t.Fatal(err)
+		}
+		out.APIVersion = in.APIVersion
+		if !equality.Semantic.DeepEqual(in, out) {
+			t.Fatalf("%s form did not round trip: %s", form, diff.ObjectReflectDiff(in, out))
+		}
+	}
+}
+
+func TestRoundTripV1beta1(t *testing.T) {
+	f := newFuzzer(t)
+	for i := 0; i < fuzzIterations; i++ {
+		in := &Website{}
+		f.Fuzz(in)
+		delete(in.Annotations, SourceFormAnnotation)
+		in.Spec.Source.Sources = nil
+		if i%3 != 0 {
+			in.Spec.Source.Sources = fuzzSources(f, i)
+		}
+
+		alpha := &v1alpha1.Website{}
+		if err := Convert_v1beta1_Website_To_v1alpha1_Website(in.DeepCopy(), alpha, nil); err != nil {
+			t.Fatal(err)
+		}
+		out := &Website{}
+		if err := Convert_v1alpha1_Website_To_v1beta1_Website(alpha.DeepCopy(), out, nil); err != nil {
+			t.Fatal(err)
+		}
+		out.APIVersion = in.APIVersion
+		if !equality.Semantic.DeepEqual(in, out) {
+			t.Fatalf("did not round trip: %s", diff.ObjectReflectDiff(in, out))
+		}
+	}
+}
+
+func TestSourceFormAnnotation(t *testing.T) {
+	git := v1alpha1.WebsiteSource{Git: &v1alpha1.GitSource{Repo: "https://github.com/nevermosby/kubia-website-example.git"}}
+	http := v1alpha1.WebsiteSource{Name: "assets", HTTP: &v1alpha1.HTTPSource{URL: "https://example.com/assets.tar.gz"}}
+
+	tests := []struct {
+		name       string
+		alpha      v1alpha1.WebsiteSpec
+		annotation string
+	}{
+		{name: "gitRepo shorthand", alpha: v1alpha1.WebsiteSpec{GitRepo: git.Git.Repo}},
+		{name: "git source", alpha: v1alpha1.WebsiteSpec{Source: &git}, annotation: sourceFormSource},
+		{name: "single git sources", alpha: v1alpha1.WebsiteSpec{Sources: []v1alpha1.WebsiteSource{git}}, annotation: sourceFormSources},
+		{name: "http source", alpha: v1alpha1.WebsiteSpec{Source: &http}},
+		{name: "single http sources", alpha: v1alpha1.WebsiteSpec{Sources: []v1alpha1.WebsiteSource{http}}, annotation: sourceFormSources},
+		{name: "several sources", alpha: v1alpha1.WebsiteSpec{Sources: []v1alpha1.WebsiteSource{git, http}}},
+		{name: "no source"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			beta := &Website{}
+			if err := Convert_v1alpha1_Website_To_v1beta1_Website(&v1alpha1.Website{Spec: tt.alpha}, beta, nil); err != nil {
+				t.Fatal(err)
+			}
+			annotation, ok := beta.Annotations[SourceFormAnnotation]
+			if annotation != tt.annotation || ok != (tt.annotation != "") {
+				t.Errorf("%s = %q (set %v), want %q", SourceFormAnnotation, annotation, ok, tt.annotation)
+			}
+		})
+	}
+
+	t.Run("form not fitting any longer", func(t *testing.T) {
+		beta := &Website{}
+		beta.Annotations = map[string]string{SourceFormAnnotation: sourceFormGitRepo, "team": "web"}
+		beta.Spec.Source.Sources = []v1alpha1.WebsiteSource{git, http}
+		alpha := &v1alpha1.Website{}
+		if err := Convert_v1beta1_Website_To_v1alpha1_Website(beta, alpha, nil); err != nil {
+			t.Fatal(err)
+		}
+		if alpha.Spec.GitRepo != "" || len(alpha.Spec.Sources) != 2 {
+			t.Errorf("sources converted to gitRepo %q and %d sources, want 2 sources", alpha.Spec.GitRepo, len(alpha.Spec.Sources))
+		}
+		if _, ok := alpha.Annotations[SourceFormAnnotation]; ok || alpha.Annotations["team"] != "web" {
+			t.Errorf("annotations = %v, want only team", alpha.Annotations)
+		}
+	})
+}
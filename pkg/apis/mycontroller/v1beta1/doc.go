@@ -0,0 +1,7 @@
+// +k8s:deepcopy-gen=package
+// +groupName=mycontroller.nevermosby.io
+
+// Package v1beta1 is the v1beta1 version of the API. It groups the flat
+// Website spec of v1alpha1 into source, service, server and scaling, and
+// reuses the v1alpha1 types of the members it did not restructure.
+package v1beta1
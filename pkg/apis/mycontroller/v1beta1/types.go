@@ -0,0 +1,120 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type Website struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WebsiteSpec            `json:"spec"`
+	Status v1alpha1.WebsiteStatus `json:"status"`
+}
+
+type WebsiteSpec struct {
+	DeploymentName string `json:"deploymentName"`
+	// Template names the WebsiteTemplate whose defaults the Website
+	// inherits.
+	Template string `json:"template,omitempty"`
+
+	// Source is where the site content comes from and how it is built.
+	Source WebsiteSourceSpec `json:"source"`
+	// Service exposes the Website.
+	Service WebsiteServiceSpec `json:"service,omitempty"`
+	// Server configures how the site is served.
+	Server WebsiteServerSpec `json:"server,omitempty"`
+	// Scaling sets how many pods serve the Website and how many may be
+	// disrupted.
+	Scaling WebsiteScaling `json:"scaling,omitempty"`
+
+	// RollbackTo pins the Website to a revision from status.history instead
+	// of following the branch. Clearing it resumes following the branch.
+	RollbackTo string `json:"rollbackTo,omitempty"`
+	// Strategy controls how new revisions are rolled out, defaults to a
+	// rolling update of the Deployment.
+	Strategy *v1alpha1.WebsiteStrategy `json:"strategy,omitempty"`
+	// Resources of the website pods, defaults come from the controller
+	// flags.
+	Resources *v1alpha1.WebsiteResources `json:"resources,omitempty"`
+
+	// NodeSelector, Tolerations, Affinity, TopologySpreadConstraints,
+	// PriorityClassName and ServiceAccountName are set on the website pods.
+	NodeSelector              map[string]string                 `json:"nodeSelector,omitempty"`
+	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty"`
+	Affinity                  *corev1.Affinity                  `json:"affinity,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	PriorityClassName         string                            `json:"priorityClassName,omitempty"`
+	ServiceAccountName        string                            `json:"serviceAccountName,omitempty"`
+	// AntiAffinity spreads the replicas across nodes unless spec.affinity
+	// sets a pod anti-affinity. Off by default.
+	AntiAffinity v1alpha1.AntiAffinityMode `json:"antiAffinity,omitempty"`
+	// PodSecurity picks how locked down the pods run, defaults to the
+	// controller flag -default-pod-security.
+	PodSecurity v1alpha1.PodSecurityMode `json:"podSecurity,omitempty"`
+	// NetworkPolicy restricts the traffic of the website pods with a
+	// NetworkPolicy owned by the Website. None is created when unset.
+	NetworkPolicy *v1alpha1.WebsiteNetworkPolicy `json:"networkPolicy,omitempty"`
+	// Deployment tunes how the website Deployments roll out.
+	Deployment *v1alpha1.WebsiteDeployment `json:"deployment,omitempty"`
+	// PodTemplateOverrides is a partial PodTemplateSpec merged into the
+	// generated pod template as a strategic merge patch.
+	PodTemplateOverrides *runtime.RawExtension `json:"podTemplateOverrides,omitempty"`
+	// AdoptExisting lets the controller take over a Deployment and Service
+	// of the names of the website that exist without a controller.
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+}
+
+// WebsiteSourceSpec replaces gitRepo, branch, source and sources of
+// v1alpha1 with a single list.
+type WebsiteSourceSpec struct {
+	// Sources compose the site, each served at its mountPath. The first one
+	// is the primary source mounted at /: revisions, rollbacks, contentPath
+	// and the build apply to it.
+	Sources []v1alpha1.WebsiteSource `json:"sources,omitempty"`
+	// ContentPath is the directory of the primary source holding the site,
+	// e.g. docs. Defaults to the whole source.
+	ContentPath string `json:"contentPath,omitempty"`
+	// Build turns the checkout into the served site before the pods serve
+	// it. Without it the checkout is served as is.
+	Build *v1alpha1.WebsiteBuild `json:"build,omitempty"`
+}
+
+type WebsiteServiceSpec struct {
+	// Type of the website Service, NodePort when unset.
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Ingress exposes the Website on a host through an Ingress.
+	Ingress *v1alpha1.WebsiteIngress `json:"ingress,omitempty"`
+}
+
+type WebsiteServerSpec struct {
+	// Config of the server. Without it the stock nginx configuration is
+	// used.
+	Config *v1alpha1.WebsiteServer `json:"config,omitempty"`
+	// Auth protects the site with HTTP basic auth.
+	Auth *v1alpha1.WebsiteAuth `json:"auth,omitempty"`
+}
+
+type WebsiteScaling struct {
+	// Replicas of the website pods, 1 when unset.
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Availability bounds the voluntary disruptions of the website pods
+	// with a PodDisruptionBudget owned by the Website.
+	Availability *v1alpha1.WebsiteAvailability `json:"availability,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type WebsiteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Website `json:"items"`
+}
@@ -22,6 +22,8 @@ import (
 	clientset "github.com/nevermosby/my-crd-controller/pkg/client/clientset/versioned"
 	mycontrollerv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/client/clientset/versioned/typed/mycontroller/v1alpha1"
 	fakemycontrollerv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/client/clientset/versioned/typed/mycontroller/v1alpha1/fake"
+	mycontrollerv1beta1 "github.com/nevermosby/my-crd-controller/pkg/client/clientset/versioned/typed/mycontroller/v1beta1"
+	fakemycontrollerv1beta1 "github.com/nevermosby/my-crd-controller/pkg/client/clientset/versioned/typed/mycontroller/v1beta1/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -80,3 +82,8 @@ var _ clientset.Interface = &Clientset{}
 func (c *Clientset) MycontrollerV1alpha1() mycontrollerv1alpha1.MycontrollerV1alpha1Interface {
 	return &fakemycontrollerv1alpha1.FakeMycontrollerV1alpha1{Fake: &c.Fake}
 }
+
+// MycontrollerV1beta1 retrieves the MycontrollerV1beta1Client
+func (c *Clientset) MycontrollerV1beta1() mycontrollerv1beta1.MycontrollerV1beta1Interface {
+	return &fakemycontrollerv1beta1.FakeMycontrollerV1beta1{Fake: &c.Fake}
+}
@@ -28,10 +28,30 @@ type FakeMycontrollerV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeMycontrollerV1alpha1) ClusterWebsites() v1alpha1.ClusterWebsiteInterface {
+	return &FakeClusterWebsites{c}
+}
+
+func (c *FakeMycontrollerV1alpha1) ClusterWebsitePolicies() v1alpha1.ClusterWebsitePolicyInterface {
+	return &FakeClusterWebsitePolicies{c}
+}
+
 func (c *FakeMycontrollerV1alpha1) Websites(namespace string) v1alpha1.WebsiteInterface {
 	return &FakeWebsites{c, namespace}
 }
 
+func (c *FakeMycontrollerV1alpha1) WebsitePolicies(namespace string) v1alpha1.WebsitePolicyInterface {
+	return &FakeWebsitePolicies{c, namespace}
+}
+
+func (c *FakeMycontrollerV1alpha1) WebsitePreviews(namespace string) v1alpha1.WebsitePreviewInterface {
+	return &FakeWebsitePreviews{c, namespace}
+}
+
+func (c *FakeMycontrollerV1alpha1) WebsiteTemplates() v1alpha1.WebsiteTemplateInterface {
+	return &FakeWebsiteTemplates{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeMycontrollerV1alpha1) RESTClient() rest.Interface {
@@ -18,4 +18,14 @@ limitations under the License.
 
 package v1alpha1
 
+type ClusterWebsiteExpansion interface{}
+
+type ClusterWebsitePolicyExpansion interface{}
+
 type WebsiteExpansion interface{}
+
+type WebsitePolicyExpansion interface{}
+
+type WebsitePreviewExpansion interface{}
+
+type WebsiteTemplateExpansion interface{}
@@ -26,7 +26,12 @@ import (
 
 type MycontrollerV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	ClusterWebsitesGetter
+	ClusterWebsitePoliciesGetter
 	WebsitesGetter
+	WebsitePoliciesGetter
+	WebsitePreviewsGetter
+	WebsiteTemplatesGetter
 }
 
 // MycontrollerV1alpha1Client is used to interact with features provided by the mycontroller.nevermosby.io group.
@@ -34,10 +39,30 @@ type MycontrollerV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *MycontrollerV1alpha1Client) ClusterWebsites() ClusterWebsiteInterface {
+	return newClusterWebsites(c)
+}
+
+func (c *MycontrollerV1alpha1Client) ClusterWebsitePolicies() ClusterWebsitePolicyInterface {
+	return newClusterWebsitePolicies(c)
+}
+
 func (c *MycontrollerV1alpha1Client) Websites(namespace string) WebsiteInterface {
 	return newWebsites(c, namespace)
 }
 
+func (c *MycontrollerV1alpha1Client) WebsitePolicies(namespace string) WebsitePolicyInterface {
+	return newWebsitePolicies(c, namespace)
+}
+
+func (c *MycontrollerV1alpha1Client) WebsitePreviews(namespace string) WebsitePreviewInterface {
+	return newWebsitePreviews(c, namespace)
+}
+
+func (c *MycontrollerV1alpha1Client) WebsiteTemplates() WebsiteTemplateInterface {
+	return newWebsiteTemplates(c)
+}
+
 // NewForConfig creates a new MycontrollerV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*MycontrollerV1alpha1Client, error) {
 	config := *c
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	v1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+	v1beta1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1beta1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -53,8 +54,22 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=mycontroller.nevermosby.io, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithResource("clusterwebsites"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Mycontroller().V1alpha1().ClusterWebsites().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("clusterwebsitepolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Mycontroller().V1alpha1().ClusterWebsitePolicies().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("websites"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Mycontroller().V1alpha1().Websites().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("websitepolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Mycontroller().V1alpha1().WebsitePolicies().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("websitepreviews"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Mycontroller().V1alpha1().WebsitePreviews().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("websitetemplates"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Mycontroller().V1alpha1().WebsiteTemplates().Informer()}, nil
+
+		// Group=mycontroller.nevermosby.io, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("websites"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Mycontroller().V1beta1().Websites().Informer()}, nil
 
 	}
 
@@ -24,8 +24,18 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ClusterWebsites returns a ClusterWebsiteInformer.
+	ClusterWebsites() ClusterWebsiteInformer
+	// ClusterWebsitePolicies returns a ClusterWebsitePolicyInformer.
+	ClusterWebsitePolicies() ClusterWebsitePolicyInformer
 	// Websites returns a WebsiteInformer.
 	Websites() WebsiteInformer
+	// WebsitePolicies returns a WebsitePolicyInformer.
+	WebsitePolicies() WebsitePolicyInformer
+	// WebsitePreviews returns a WebsitePreviewInformer.
+	WebsitePreviews() WebsitePreviewInformer
+	// WebsiteTemplates returns a WebsiteTemplateInformer.
+	WebsiteTemplates() WebsiteTemplateInformer
 }
 
 type version struct {
@@ -39,7 +49,32 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ClusterWebsites returns a ClusterWebsiteInformer.
+func (v *version) ClusterWebsites() ClusterWebsiteInformer {
+	return &clusterWebsiteInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// ClusterWebsitePolicies returns a ClusterWebsitePolicyInformer.
+func (v *version) ClusterWebsitePolicies() ClusterWebsitePolicyInformer {
+	return &clusterWebsitePolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // Websites returns a WebsiteInformer.
 func (v *version) Websites() WebsiteInformer {
 	return &websiteInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// WebsitePolicies returns a WebsitePolicyInformer.
+func (v *version) WebsitePolicies() WebsitePolicyInformer {
+	return &websitePolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// WebsitePreviews returns a WebsitePreviewInformer.
+func (v *version) WebsitePreviews() WebsitePreviewInformer {
+	return &websitePreviewInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// WebsiteTemplates returns a WebsiteTemplateInformer.
+func (v *version) WebsiteTemplates() WebsiteTemplateInformer {
+	return &websiteTemplateInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -18,6 +18,14 @@ limitations under the License.
 
 package v1alpha1
 
+// ClusterWebsiteListerExpansion allows custom methods to be added to
+// ClusterWebsiteLister.
+type ClusterWebsiteListerExpansion interface{}
+
+// ClusterWebsitePolicyListerExpansion allows custom methods to be added to
+// ClusterWebsitePolicyLister.
+type ClusterWebsitePolicyListerExpansion interface{}
+
 // WebsiteListerExpansion allows custom methods to be added to
 // WebsiteLister.
 type WebsiteListerExpansion interface{}
@@ -25,3 +33,23 @@ type WebsiteListerExpansion interface{}
 // WebsiteNamespaceListerExpansion allows custom methods to be added to
 // WebsiteNamespaceLister.
 type WebsiteNamespaceListerExpansion interface{}
+
+// WebsitePolicyListerExpansion allows custom methods to be added to
+// WebsitePolicyLister.
+type WebsitePolicyListerExpansion interface{}
+
+// WebsitePolicyNamespaceListerExpansion allows custom methods to be added to
+// WebsitePolicyNamespaceLister.
+type WebsitePolicyNamespaceListerExpansion interface{}
+
+// WebsitePreviewListerExpansion allows custom methods to be added to
+// WebsitePreviewLister.
+type WebsitePreviewListerExpansion interface{}
+
+// WebsitePreviewNamespaceListerExpansion allows custom methods to be added to
+// WebsitePreviewNamespaceLister.
+type WebsitePreviewNamespaceListerExpansion interface{}
+
+// WebsiteTemplateListerExpansion allows custom methods to be added to
+// WebsiteTemplateLister.
+type WebsiteTemplateListerExpansion interface{}
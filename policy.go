@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// appliedPolicy is a WebsitePolicy or ClusterWebsitePolicy applying to a
+// namespace, named the way violations quote it.
+type appliedPolicy struct {
+	name string
+	spec *myv1alpha1.WebsitePolicySpec
+	// err is set for a policy that cannot be evaluated, which every website
+	// violates rather than be exempted by it.
+	err error
+}
+
+// websitePolicies returns the policies applying to the websites of
+// namespace: its WebsitePolicies and the ClusterWebsitePolicies selecting it.
+func (c *Controller) websitePolicies(namespace string) ([]appliedPolicy, error) {
+	var policies []appliedPolicy
+	namespaced, err := c.policiesLister.WebsitePolicies(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, policy := range namespaced {
+		policies = append(policies, appliedPolicy{name: "WebsitePolicy " + policy.Name, spec: &policy.Spec})
+	}
+
+	cluster, err := c.clusterPoliciesLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	if len(cluster) == 0 {
+		return policies, nil
+	}
+	ns, err := c.namespacesLister.Get(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, policy := range cluster {
+		if policy.Spec.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+			if err != nil {
+				policies = append(policies, appliedPolicy{
+					name: "ClusterWebsitePolicy " + policy.Name,
+					spec: &policy.Spec.WebsitePolicySpec,
+					err:  fmt.Errorf("namespaceSelector: %v", err),
+				})
+				continue
+			}
+			if !selector.Matches(labels.Set(ns.Labels)) {
+				continue
+			}
+		}
+		policies = append(policies, appliedPolicy{name: "ClusterWebsitePolicy " + policy.Name, spec: &policy.Spec.WebsitePolicySpec})
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].name < policies[j].name })
+	return policies, nil
+}
+
+// checkWebsitePolicies returns the violations of policies by website, others
+// being the other websites of its namespace. Only the others created before
+// website count against the namespace replicas, so the oldest websites get
+// theirs first.
+func checkWebsitePolicies(website *myv1alpha1.Website, others []*myv1alpha1.Website, policies []appliedPolicy) []string {
+	var violations []string
+	violate := func(policy appliedPolicy, format string, args ...interface{}) {
+		violations = append(violations, fmt.Sprintf("%s: %s", policy.name, fmt.Sprintf(format, args...)))
+	}
+
+	replicas := websiteReplicas(website)
+	namespaceReplicas := replicas
+	for _, other := range others {
+		if other.Name != website.Name && createdBefore(other, website) {
+			namespaceReplicas += websiteReplicas(other)
+		}
+	}
+	serviceType := websiteServiceType(website)
+
+	for _, policy := range policies {
+		if policy.err != nil {
+			violate(policy, "%v", policy.err)
+			continue
+		}
+		spec := policy.spec
+		for _, source := range websiteSources(website) {
+			if source.Git == nil || len(spec.AllowedGitHosts) == 0 {
+				continue
+			}
+			host := gitHost(source.Git.Repo)
+			if !containsFold(spec.AllowedGitHosts, host) {
+				violate(policy, "git host %q of %s is not allowed", host, source.Git.Repo)
+			}
+		}
+		if len(spec.AllowedURLPatterns) > 0 {
+			patterns, err := compileURLPatterns(spec.AllowedURLPatterns)
+			if err != nil {
+				violate(policy, "%v", err)
+			}
+			for _, source := range websiteSources(website) {
+				location := sourceLocation(source)
+				if location != "" && !matchesAny(patterns, location) {
+					violate(policy, "source %q matches none of the allowed URL patterns", location)
+				}
+			}
+		}
+		if spec.MaxReplicas != nil && replicas > *spec.MaxReplicas {
+			violate(policy, "%d replicas exceed the maximum of %d", replicas, *spec.MaxReplicas)
+		}
+		if spec.MaxNamespaceReplicas != nil && namespaceReplicas > *spec.MaxNamespaceReplicas {
+			violate(policy, "%d replicas in the namespace exceed the maximum of %d", namespaceReplicas, *spec.MaxNamespaceReplicas)
+		}
+		if len(spec.AllowedServiceTypes) > 0 && !containsServiceType(spec.AllowedServiceTypes, serviceType) {
+			violate(policy, "service type %s is not allowed", serviceType)
+		}
+		for _, key := range spec.RequiredLabels {
+			if _, ok := website.Labels[key]; !ok {
+				violate(policy, "required label %q is missing", key)
+			}
+		}
+	}
+	return violations
+}
+
+// syncPolicies sets the PolicyViolated condition of the website and returns
+// its violations. New violations fire a warning event.
+func (c *Controller) syncPolicies(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus) ([]string, error) {
+	if isClusterWebsite(website) {
+		// Owned by the platform team, which the policies do not restrict
+		removeWebsiteCondition(status, myv1alpha1.WebsitePolicyViolated)
+		return nil, nil
+	}
+	policies, err := c.websitePolicies(website.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		removeWebsiteCondition(status, myv1alpha1.WebsitePolicyViolated)
+		return nil, nil
+	}
+	others, err := c.websitesLister.Websites(website.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	violations := checkWebsitePolicies(website, c.resolveWebsites(others), policies)
+	if len(violations) == 0 {
+		setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsitePolicyViolated, corev1.ConditionFalse, PoliciesSatisfied, ""))
+		return nil, nil
+	}
+	msg := strings.Join(violations, "; ")
+	if cond := getWebsiteCondition(*status, myv1alpha1.WebsitePolicyViolated); cond == nil || cond.Status != corev1.ConditionTrue || cond.Message != msg {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrPolicyViolation, msg)
+	}
+	setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsitePolicyViolated, corev1.ConditionTrue, ErrPolicyViolation, msg))
+	return violations, nil
+}
+
+// enqueueNamespaceWebsites enqueues the websites of the namespace of obj,
+// a WebsitePolicy or a Namespace, or of all namespaces for a
+// ClusterWebsitePolicy.
+func (c *Controller) enqueueNamespaceWebsites(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	var websites []*myv1alpha1.Website
+	var err error
+	switch object := obj.(type) {
+	case *myv1alpha1.ClusterWebsitePolicy:
+		websites, err = c.websitesLister.List(labels.Everything())
+	case *corev1.Namespace:
+		websites, err = c.websitesLister.Websites(object.Name).List(labels.Everything())
+	case metav1.Object:
+		websites, err = c.websitesLister.Websites(object.GetNamespace()).List(labels.Everything())
+	default:
+		err = fmt.Errorf("error decoding object, invalid type %T", obj)
+	}
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, website := range websites {
+		c.enqueueWebsite(website)
+		// previews are held to the policies of their website's namespace
+		c.enqueueWebsitePreviews(website)
+	}
+}
+
+// websiteServiceType returns the type of the website Service.
+func websiteServiceType(website *myv1alpha1.Website) corev1.ServiceType {
+	if website.Spec.ServiceType == "" {
+		return corev1.ServiceTypeNodePort
+	}
+	return website.Spec.ServiceType
+}
+
+// validateServiceType returns an error when spec.serviceType is not a type
+// a website can be exposed with.
+func validateServiceType(website *myv1alpha1.Website) error {
+	switch website.Spec.ServiceType {
+	case "", corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+		return nil
+	}
+	return fmt.Errorf("spec.serviceType: unsupported type %q, expected %s, %s or %s", website.Spec.ServiceType, corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer)
+}
+
+// createdBefore orders websites, or previews, by age, then name. Objects
+// not created yet, e.g. under admission, come last.
+func createdBefore(a, b metav1.Object) bool {
+	ta, tb := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if ta.IsZero() != tb.IsZero() {
+		return tb.IsZero()
+	}
+	if !ta.Equal(&tb) {
+		return ta.Before(&tb)
+	}
+	return a.GetName() < b.GetName()
+}
+
+// gitHost returns the host of a git repository, empty for a local one.
+func gitHost(repo string) string {
+	if !strings.Contains(repo, "://") {
+		if i := strings.Index(repo, ":"); i > 0 && !strings.HasPrefix(repo, "/") {
+			// scp-like syntax, user@host:path
+			host := repo[:i]
+			if at := strings.LastIndex(host, "@"); at >= 0 {
+				host = host[at+1:]
+			}
+			return strings.ToLower(host)
+		}
+		return ""
+	}
+	u, err := url.Parse(repo)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// sourceLocation returns where a source is fetched from, matched against
+// the allowed URL patterns. S3 objects are located as s3://bucket behind
+// their endpoint.
+func sourceLocation(source myv1alpha1.WebsiteSource) string {
+	switch {
+	case source.Git != nil:
+		return source.Git.Repo
+	case source.HTTP != nil:
+		return source.HTTP.URL
+	case source.OCI != nil:
+		return source.OCI.Reference
+	case source.S3 != nil:
+		if source.S3.Endpoint == "" {
+			return "s3://" + source.S3.Bucket
+		}
+		return strings.TrimSuffix(source.S3.Endpoint, "/") + "/" + source.S3.Bucket
+	}
+	return ""
+}
+
+// compileURLPatterns compiles the allowed URL patterns, each anchored so it
+// has to match a location in full.
+func compileURLPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("allowedURLPatterns[%d]: %v", i, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsServiceType(list []corev1.ServiceType, t corev1.ServiceType) bool {
+	for _, item := range list {
+		if item == t {
+			return true
+		}
+	}
+	return false
+}
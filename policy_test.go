@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+	listers "github.com/nevermosby/my-crd-controller/pkg/client/listers/mycontroller/v1alpha1"
+)
+
+func TestCheckWebsitePolicies(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	website := func(name string, replicas int32, created time.Duration, mutate func(*myv1alpha1.Website)) *myv1alpha1.Website {
+		website := &myv1alpha1.Website{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "default",
+				Name:              name,
+				Labels:            map[string]string{"team": "web"},
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-created)),
+			},
+			Spec: myv1alpha1.WebsiteSpec{
+				DeploymentName: name,
+				GitRepo:        "https://github.com/nevermosby/kubia-website-example.git",
+				Replicas:       &replicas,
+			},
+		}
+		if mutate != nil {
+			mutate(website)
+		}
+		return website
+	}
+	policy := func(spec myv1alpha1.WebsitePolicySpec) []appliedPolicy {
+		return []appliedPolicy{{name: "WebsitePolicy limits", spec: &spec}}
+	}
+	sources := func(sources ...myv1alpha1.WebsiteSource) func(*myv1alpha1.Website) {
+		return func(w *myv1alpha1.Website) { w.Spec.Sources = sources }
+	}
+
+	tests := []struct {
+		name     string
+		website  *myv1alpha1.Website
+		others   []*myv1alpha1.Website
+		policies []appliedPolicy
+		want     []string
+	}{
+		{
+			name:     "no policies",
+			website:  website("kubia", 10, 0, nil),
+			policies: nil,
+		},
+		{
+			name:     "allowed git host",
+			website:  website("kubia", 1, 0, nil),
+			policies: policy(myv1alpha1.WebsitePolicySpec{AllowedGitHosts: []string{"GitHub.com"}}),
+		},
+		{
+			name:     "scp-like git host",
+			website:  website("kubia", 1, 0, func(w *myv1alpha1.Website) { w.Spec.GitRepo = "git@gitlab.com:nevermosby/site.git" }),
+			policies: policy(myv1alpha1.WebsitePolicySpec{AllowedGitHosts: []string{"github.com"}}),
+			want:     []string{`WebsitePolicy limits: git host "gitlab.com" of git@gitlab.com:nevermosby/site.git is not allowed`},
+		},
+		{
+			name:     "git hosts do not restrict other sources",
+			website:  website("kubia", 1, 0, sources(myv1alpha1.WebsiteSource{Name: "docs", HTTP: &myv1alpha1.HTTPSource{URL: "https://example.com/site.tgz"}})),
+			policies: policy(myv1alpha1.WebsitePolicySpec{AllowedGitHosts: []string{"github.com"}}),
+		},
+		{
+			name: "URL patterns match every source in full",
+			website: website("kubia", 1, 0, sources(
+				myv1alpha1.WebsiteSource{Name: "site", Git: &myv1alpha1.GitSource{Repo: "https://github.com/nevermosby/site.git"}},
+				myv1alpha1.WebsiteSource{Name: "docs", OCI: &myv1alpha1.OCISource{Reference: "ghcr.io/nevermosby/docs:v1"}},
+				myv1alpha1.WebsiteSource{Name: "assets", S3: &myv1alpha1.S3Source{Endpoint: "http://minio:9000/", Bucket: "assets"}},
+				myv1alpha1.WebsiteSource{Name: "evil", HTTP: &myv1alpha1.HTTPSource{URL: "https://github.com/nevermosby/site.git.evil.com/a.tgz"}},
+			)),
+			policies: policy(myv1alpha1.WebsitePolicySpec{AllowedURLPatterns: []string{`https://github\.com/nevermosby/.*\.git`, `ghcr\.io/nevermosby/.*`, `http://minio:9000/assets`}}),
+			want:     []string{`WebsitePolicy limits: source "https://github.com/nevermosby/site.git.evil.com/a.tgz" matches none of the allowed URL patterns`},
+		},
+		{
+			name:     "S3 without endpoint",
+			website:  website("kubia", 1, 0, sources(myv1alpha1.WebsiteSource{Name: "site", S3: &myv1alpha1.S3Source{Bucket: "site"}})),
+			policies: policy(myv1alpha1.WebsitePolicySpec{AllowedURLPatterns: []string{`s3://site`}}),
+		},
+		{
+			name:     "malformed URL pattern",
+			website:  website("kubia", 1, 0, nil),
+			policies: policy(myv1alpha1.WebsitePolicySpec{AllowedURLPatterns: []string{`(`}}),
+			want: []string{
+				"WebsitePolicy limits: allowedURLPatterns[0]: error parsing regexp: missing closing ): `^(?:()$`",
+				`WebsitePolicy limits: source "https://github.com/nevermosby/kubia-website-example.git" matches none of the allowed URL patterns`,
+			},
+		},
+		{
+			name:     "replicas at the maximum",
+			website:  website("kubia", 3, 0, nil),
+			policies: policy(myv1alpha1.WebsitePolicySpec{MaxReplicas: int32Ptr(3)}),
+		},
+		{
+			name:     "replicas above the maximum",
+			website:  website("kubia", 4, 0, nil),
+			policies: policy(myv1alpha1.WebsitePolicySpec{MaxReplicas: int32Ptr(3)}),
+			want:     []string{"WebsitePolicy limits: 4 replicas exceed the maximum of 3"},
+		},
+		{
+			name:     "unset replicas count as one",
+			website:  website("kubia", 0, 0, func(w *myv1alpha1.Website) { w.Spec.Replicas = nil }),
+			policies: policy(myv1alpha1.WebsitePolicySpec{MaxReplicas: int32Ptr(1)}),
+		},
+		{
+			name:    "older websites get their namespace replicas first",
+			website: website("kubia", 2, time.Minute, nil),
+			others: []*myv1alpha1.Website{
+				website("kubia", 2, time.Minute, nil),
+				website("older", 3, time.Hour, nil),
+				website("later", 5, 0, nil),
+			},
+			policies: policy(myv1alpha1.WebsitePolicySpec{MaxNamespaceReplicas: int32Ptr(5)}),
+		},
+		{
+			name:    "later website exceeds the namespace replicas",
+			website: website("later", 1, 0, nil),
+			others: []*myv1alpha1.Website{
+				website("kubia", 2, time.Minute, nil),
+				website("older", 3, time.Hour, nil),
+			},
+			policies: policy(myv1alpha1.WebsitePolicySpec{MaxNamespaceReplicas: int32Ptr(5)}),
+			want:     []string{"WebsitePolicy limits: 6 replicas in the namespace exceed the maximum of 5"},
+		},
+		{
+			name:     "default service type",
+			website:  website("kubia", 1, 0, nil),
+			policies: policy(myv1alpha1.WebsitePolicySpec{AllowedServiceTypes: []corev1.ServiceType{corev1.ServiceTypeClusterIP}}),
+			want:     []string{"WebsitePolicy limits: service type NodePort is not allowed"},
+		},
+		{
+			name:     "allowed service type",
+			website:  website("kubia", 1, 0, func(w *myv1alpha1.Website) { w.Spec.ServiceType = corev1.ServiceTypeClusterIP }),
+			policies: policy(myv1alpha1.WebsitePolicySpec{AllowedServiceTypes: []corev1.ServiceType{corev1.ServiceTypeClusterIP}}),
+		},
+		{
+			name:     "required labels",
+			website:  website("kubia", 1, 0, nil),
+			policies: policy(myv1alpha1.WebsitePolicySpec{RequiredLabels: []string{"team", "cost-center"}}),
+			want:     []string{`WebsitePolicy limits: required label "cost-center" is missing`},
+		},
+		{
+			name:    "every policy applies",
+			website: website("kubia", 4, 0, nil),
+			policies: append(policy(myv1alpha1.WebsitePolicySpec{MaxReplicas: int32Ptr(3)}),
+				appliedPolicy{name: "ClusterWebsitePolicy tenants", spec: &myv1alpha1.WebsitePolicySpec{MaxReplicas: int32Ptr(2)}}),
+			want: []string{
+				"WebsitePolicy limits: 4 replicas exceed the maximum of 3",
+				"ClusterWebsitePolicy tenants: 4 replicas exceed the maximum of 2",
+			},
+		},
+		{
+			name:     "policy that cannot be evaluated",
+			website:  website("kubia", 1, 0, nil),
+			policies: []appliedPolicy{{name: "ClusterWebsitePolicy broken", spec: &myv1alpha1.WebsitePolicySpec{}, err: fmt.Errorf("namespaceSelector: invalid")}},
+			want:     []string{"ClusterWebsitePolicy broken: namespaceSelector: invalid"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkWebsitePolicies(tt.website, tt.others, tt.policies)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("checkWebsitePolicies() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebsitePolicies(t *testing.T) {
+	policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	clusterPolicies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, obj := range []interface{}{
+		&myv1alpha1.WebsitePolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "limits"}},
+		&myv1alpha1.WebsitePolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "limits"}},
+	} {
+		if err := policies.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, obj := range []interface{}{
+		&myv1alpha1.ClusterWebsitePolicy{ObjectMeta: metav1.ObjectMeta{Name: "all"}},
+		&myv1alpha1.ClusterWebsitePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+			Spec: myv1alpha1.ClusterWebsitePolicySpec{NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"tenant": "true"},
+			}},
+		},
+		&myv1alpha1.ClusterWebsitePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+			Spec: myv1alpha1.ClusterWebsitePolicySpec{NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tenant", Operator: "Near"}},
+			}},
+		},
+	} {
+		if err := clusterPolicies.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, obj := range []interface{}{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"tenant": "true"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	} {
+		if err := namespaces.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := &Controller{
+		policiesLister:        listers.NewWebsitePolicyLister(policies),
+		clusterPoliciesLister: listers.NewClusterWebsitePolicyLister(clusterPolicies),
+		namespacesLister:      corelisters.NewNamespaceLister(namespaces),
+	}
+
+	for _, tt := range []struct {
+		namespace string
+		want      []string
+	}{
+		{namespace: "web", want: []string{"ClusterWebsitePolicy all", "ClusterWebsitePolicy broken", "ClusterWebsitePolicy tenants", "WebsitePolicy limits"}},
+		{namespace: "kube-system", want: []string{"ClusterWebsitePolicy all", "ClusterWebsitePolicy broken"}},
+	} {
+		got, err := c.websitePolicies(tt.namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, policy := range got {
+			names = append(names, policy.name)
+			if (policy.err != nil) != (policy.name == "ClusterWebsitePolicy broken") {
+				t.Errorf("policy %s in %s error = %v", policy.name, tt.namespace, policy.err)
+			}
+		}
+		if strings.Join(names, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("policies of %s = %v, want %v", tt.namespace, names, tt.want)
+		}
+	}
+}
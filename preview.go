@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// previewWebsiteIndex is the name of the WebsitePreview informer index
+	// keyed by the namespace/name of the parent Website.
+	previewWebsiteIndex = "website"
+
+	// maxHostLabel is the longest DNS label a branch slug may use.
+	maxHostLabel = 63
+)
+
+// indexPreviewByWebsite is the cache.IndexFunc for previewWebsiteIndex.
+func indexPreviewByWebsite(obj interface{}) ([]string, error) {
+	preview, ok := obj.(*myv1alpha1.WebsitePreview)
+	if !ok || preview.Spec.Website == "" {
+		return nil, nil
+	}
+	return []string{preview.Namespace + "/" + preview.Spec.Website}, nil
+}
+
+// enqueuePreview puts a WebsitePreview on the preview workqueue.
+func (c *Controller) enqueuePreview(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.previewsWorkqueue.Add(key)
+}
+
+// enqueueWebsitePreviews enqueues the previews of a website, so they pick up
+// changes to its repository and ingress.
+func (c *Controller) enqueueWebsitePreviews(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	previews, err := c.previewsIndexer.ByIndex(previewWebsiteIndex, key)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, preview := range previews {
+		c.enqueuePreview(preview)
+	}
+}
+
+// enqueueAllPreviews enqueues every WebsitePreview. Previews are few, and
+// the host a preview derives from its branch is not indexed.
+func (c *Controller) enqueueAllPreviews() {
+	previews, err := c.previewsLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, preview := range previews {
+		c.enqueuePreview(preview)
+	}
+}
+
+// syncPreview brings the Deployment, Service and Ingress of a WebsitePreview
+// in line with its parent website and branch, and deletes the preview once
+// its TTL elapsed.
+func (c *Controller) syncPreview(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+	preview, err := c.previewsLister.WebsitePreviews(namespace).Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			utilruntime.HandleError(fmt.Errorf("website preview '%s' in work queue no longer exists", key))
+			return nil
+		}
+		return err
+	}
+	if preview.DeletionTimestamp != nil {
+		return nil
+	}
+
+	status := preview.Status.DeepCopy()
+	status.ExpirationTime = previewExpiration(preview)
+	if status.ExpirationTime != nil {
+		remaining := time.Until(status.ExpirationTime.Time)
+		if remaining <= 0 {
+			// The owned Deployment, Service and Ingress are garbage collected
+			klog.Infof("Website preview '%s' expired, deleting it", key)
+			c.recorder.Event(preview, corev1.EventTypeNormal, PreviewExpired, MessagePreviewExpired)
+			err := c.sampleclientset.MycontrollerV1alpha1().WebsitePreviews(namespace).Delete(name, &metav1.DeleteOptions{})
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		c.previewsWorkqueue.AddAfter(key, remaining)
+	}
+
+	parent, err := c.websitesLister.Websites(namespace).Get(preview.Spec.Website)
+	if errors.IsNotFound(err) {
+		// Keep what runs, the preview is synced again once the website exists
+		status.Message = fmt.Sprintf(MessagePreviewWebsiteNotFound, preview.Spec.Website)
+		c.recorder.Event(preview, corev1.EventTypeWarning, ErrPreviewWebsite, status.Message)
+		return c.updatePreviewStatus(preview, status, nil)
+	}
+	if err != nil {
+		return err
+	}
+	// Previews inherit from the template of their website as well
+	if parent, _, err = c.resolveWebsite(parent); err != nil {
+		status.Message = err.Error()
+		c.recorder.Event(preview, corev1.EventTypeWarning, ErrPreviewWebsite, status.Message)
+		return c.updatePreviewStatus(preview, status, nil)
+	}
+	if websiteGitSource(parent) == nil {
+		// Only branches can be previewed
+		status.Message = fmt.Sprintf(MessagePreviewWebsiteNotGit, preview.Spec.Website)
+		c.recorder.Event(preview, corev1.EventTypeWarning, ErrPreviewWebsite, status.Message)
+		return c.updatePreviewStatus(preview, status, nil)
+	}
+
+	site := previewWebsite(preview, parent)
+	// Keep what runs, the preview is synced again once it is fixed or the
+	// names it wants are released
+	problems, err := c.checkPreview(preview, parent, site)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		msg := strings.Join(problems, "; ")
+		if status.Message != msg {
+			c.recorder.Event(preview, corev1.EventTypeWarning, ErrInvalidPreview, msg)
+		}
+		status.Message = msg
+		utilruntime.HandleError(fmt.Errorf("%s: %s", key, msg))
+		return c.updatePreviewStatus(preview, status, nil)
+	}
+
+	c.syncPreviewRevision(preview, parent, status)
+
+	if serverConfigManaged(site) {
+		configMap := newConfigMap(site)
+		configMap.OwnerReferences = previewOwnerReferences(preview)
+		if _, err := c.syncConfigMap(preview, configMap); err != nil {
+			return err
+		}
+	} else if err := c.deleteConfigMap(preview, configName(site)); err != nil {
+		return err
+	}
+	deployment, err := c.syncDeployment(preview, newPreviewDeployment(preview, site, status.Revision))
+	if err != nil {
+		return err
+	}
+	if _, err := c.syncService(preview, newPreviewService(preview, site)); err != nil {
+		return err
+	}
+	if host := previewHost(preview, parent); host != "" {
+		if _, err := c.syncIngressObject(preview, newPreviewIngress(preview, site)); err != nil {
+			return err
+		}
+		status.URL = "http://" + host
+	} else {
+		if err := c.deleteIngress(preview, previewName(preview)); err != nil {
+			return err
+		}
+		status.URL = fmt.Sprintf("http://%s.%s.svc", previewName(preview)+"-npsvc", preview.Namespace)
+	}
+
+	if err := c.updatePreviewStatus(preview, status, deployment); err != nil {
+		return err
+	}
+	c.previewsWorkqueue.AddAfter(key, c.gitPollInterval)
+	return nil
+}
+
+// syncPreviewRevision resolves the previewed branch to a commit SHA when it
+// was not resolved yet, the repository changed or the poll interval elapsed.
+// The preview keeps its revision when the branch cannot be resolved.
+func (c *Controller) syncPreviewRevision(preview *myv1alpha1.WebsitePreview, parent *myv1alpha1.Website, status *myv1alpha1.WebsitePreviewStatus) {
+	ref := websiteRepo(parent) + "#" + preview.Spec.Branch
+	if status.LastResolveTime != nil && status.ResolvedRef == ref && time.Since(status.LastResolveTime.Time) < c.gitPollInterval {
+		return
+	}
+	revision, ok, err := c.resolve(websiteRepo(parent), preview.Spec.Branch, func() { c.enqueuePreview(preview) })
+	if !ok {
+		// synced again once resolved
+		return
+	}
+	now := metav1.Now()
+	status.LastResolveTime = &now
+	if err != nil {
+		if status.ResolvedRef != ref {
+			status.Revision = ""
+			status.ResolvedRef = ref
+		}
+		status.Message = err.Error()
+		c.recorder.Event(preview, corev1.EventTypeWarning, ErrResolveRevision, err.Error())
+		return
+	}
+	if revision != status.Revision {
+		c.recorder.Eventf(preview, corev1.EventTypeNormal, RevisionResolved, MessageRevisionResolved, preview.Spec.Branch, revision)
+	}
+	status.Revision = revision
+	status.ResolvedRef = ref
+	status.Message = ""
+}
+
+func (c *Controller) updatePreviewStatus(preview *myv1alpha1.WebsitePreview, status *myv1alpha1.WebsitePreviewStatus, deployment *appsv1.Deployment) error {
+	previewCopy := preview.DeepCopy()
+	previewCopy.Status = *status
+	if deployment != nil {
+		previewCopy.Status.AvailableReplicas = deployment.Status.AvailableReplicas
+	}
+	if equality.Semantic.DeepEqual(preview.Status, previewCopy.Status) {
+		return nil
+	}
+	_, err := c.sampleclientset.MycontrollerV1alpha1().WebsitePreviews(preview.Namespace).Update(previewCopy)
+	return err
+}
+
+// previewExpiration returns when the preview expires, or nil without TTL.
+func previewExpiration(preview *myv1alpha1.WebsitePreview) *metav1.Time {
+	if preview.Spec.TTL == nil {
+		return nil
+	}
+	expiration := metav1.NewTime(preview.CreationTimestamp.Add(preview.Spec.TTL.Duration))
+	return &expiration
+}
+
+// previewName returns the name of the preview Deployment and Ingress.
+func previewName(preview *myv1alpha1.WebsitePreview) string {
+	return preview.Name + "-preview"
+}
+
+// previewLabels returns the labels of the preview pods, which must not be
+// selected by the parent website service.
+func previewLabels(preview *myv1alpha1.WebsitePreview) map[string]string {
+	return map[string]string{
+		"app":        "website-nginx-preview",
+		"controller": preview.Name,
+	}
+}
+
+// previewWebsite returns the parent website reduced to what a preview runs:
+// a single replica of the previewed branch on the preview host, without
+// rollout strategy. It is as old as the preview.
+func previewWebsite(preview *myv1alpha1.WebsitePreview, parent *myv1alpha1.Website) *myv1alpha1.Website {
+	site := parent.DeepCopy()
+	site.Name = preview.Name
+	site.CreationTimestamp = preview.CreationTimestamp
+	if len(site.Spec.Sources) > 0 {
+		site.Spec.Sources[0].Git.Branch = preview.Spec.Branch
+	} else if site.Spec.Source != nil {
+		site.Spec.Source.Git.Branch = preview.Spec.Branch
+	} else {
+		site.Spec.Branch = preview.Spec.Branch
+	}
+	site.Spec.DeploymentName = previewName(preview)
+	site.Spec.Replicas = nil
+	site.Spec.RollbackTo = ""
+	site.Spec.Strategy = nil
+	if host := previewHost(preview, parent); host != "" {
+		if site.Spec.Ingress == nil {
+			site.Spec.Ingress = &myv1alpha1.WebsiteIngress{}
+		}
+		site.Spec.Ingress.Host = host
+	}
+	return site
+}
+
+// validatePreview checks the spec of a WebsitePreview: the branch must be
+// one git accepts, and name a host unless spec.host is set.
+func validatePreview(preview *myv1alpha1.WebsitePreview) error {
+	if preview.Spec.Website == "" {
+		return fmt.Errorf("spec.website: must be specified")
+	}
+	if preview.Spec.Branch == "" {
+		return fmt.Errorf("spec.branch: must be specified")
+	}
+	if err := validateBranch(preview.Spec.Branch); err != nil {
+		return err
+	}
+	if preview.Spec.Host != "" {
+		if errs := validation.IsDNS1123Subdomain(preview.Spec.Host); len(errs) > 0 {
+			return fmt.Errorf("spec.host: %s", strings.Join(errs, ", "))
+		}
+		return nil
+	}
+	if branchSlug(preview.Spec.Branch) == "" {
+		return fmt.Errorf("spec.branch: %q has no letter or digit to name the preview host, set spec.host", preview.Spec.Branch)
+	}
+	return nil
+}
+
+// checkPreview returns why the preview cannot run site, the website it
+// runs: the preview or site is invalid, site violates the policies of its
+// namespace, or takes a name held by another site. The err is for failures
+// to tell.
+func (c *Controller) checkPreview(preview *myv1alpha1.WebsitePreview, parent, site *myv1alpha1.Website) ([]string, error) {
+	if err := validatePreview(preview); err != nil {
+		return []string{err.Error()}, nil
+	}
+	if err := validateWebsite(site); err != nil {
+		return []string{err.Error()}, nil
+	}
+	policies, err := c.websitePolicies(site.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) > 0 {
+		others, err := c.websitesLister.Websites(site.Namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		if violations := checkWebsitePolicies(site, c.resolveWebsites(others), policies); len(violations) > 0 {
+			return violations, nil
+		}
+	}
+	return c.previewConflicts(preview, parent, site)
+}
+
+// previewConflicts returns the names site, the website the preview runs,
+// shares with Websites and ClusterWebsites, which always keep them, and the
+// host it shares with older previews. The name of site is not one of them,
+// preview pods are told apart from website pods by their app label.
+func (c *Controller) previewConflicts(preview *myv1alpha1.WebsitePreview, parent, site *myv1alpha1.Website) ([]string, error) {
+	var keys []uniqueKey
+	for _, key := range websiteUniqueKeys(site) {
+		if key.index != websiteNameIndex {
+			keys = append(keys, key)
+		}
+	}
+	conflicts, err := c.nameConflicts(keys, func(*myv1alpha1.Website, uniqueKey) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	host := previewHost(preview, parent)
+	if host == "" {
+		return conflicts, nil
+	}
+	previews, err := c.previewsLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range previews {
+		if other.Namespace == preview.Namespace && other.Name == preview.Name || !createdBefore(other, preview) {
+			continue
+		}
+		// The host derived from the branch is only known for the previews
+		// of the same website
+		otherHost := other.Spec.Host
+		if otherHost == "" && other.Namespace == preview.Namespace && other.Spec.Website == preview.Spec.Website {
+			otherHost = previewHost(other, parent)
+		}
+		if otherHost != "" && strings.EqualFold(otherHost, host) {
+			conflicts = append(conflicts, fmt.Sprintf("host %q is used by WebsitePreview %s/%s", strings.ToLower(host), other.Namespace, other.Name))
+		}
+	}
+	return conflicts, nil
+}
+
+// previewHost returns the hostname of the preview: spec.host, or the branch
+// as a subdomain of the parent's ingress host. It is empty when the parent
+// has no ingress.
+func previewHost(preview *myv1alpha1.WebsitePreview, parent *myv1alpha1.Website) string {
+	if preview.Spec.Host != "" {
+		return preview.Spec.Host
+	}
+	if parent.Spec.Ingress == nil || parent.Spec.Ingress.Host == "" {
+		return ""
+	}
+	return branchSlug(preview.Spec.Branch) + "." + parent.Spec.Ingress.Host
+}
+
+// branchSlug turns a branch name like feature/New_Header into a DNS label
+// like feature-new-header.
+func branchSlug(branch string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(branch) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := b.String()
+	if len(slug) > maxHostLabel {
+		slug = slug[:maxHostLabel]
+	}
+	return strings.TrimRight(slug, "-")
+}
+
+// previewOwnerReferences makes the preview the controller of its objects.
+func previewOwnerReferences(preview *myv1alpha1.WebsitePreview) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		*metav1.NewControllerRef(preview, myv1alpha1.SchemeGroupVersion.WithKind("WebsitePreview")),
+	}
+}
+
+// newPreviewDeployment creates the Deployment serving the previewed branch.
+func newPreviewDeployment(preview *myv1alpha1.WebsitePreview, site *myv1alpha1.Website, revision string) *appsv1.Deployment {
+	deployment := newDeployment(site, revision)
+	labels := previewLabels(preview)
+	deployment.OwnerReferences = previewOwnerReferences(preview)
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	deployment.Spec.Template.Labels = labels
+	return deployment
+}
+
+// newPreviewService creates the Service selecting the preview pods.
+func newPreviewService(preview *myv1alpha1.WebsitePreview, site *myv1alpha1.Website) *corev1.Service {
+	service := newService(site)
+	service.OwnerReferences = previewOwnerReferences(preview)
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	service.Spec.Selector = previewLabels(preview)
+	return service
+}
+
+// newPreviewIngress creates the Ingress routing the preview host to the
+// preview service, with the ingress annotations of the parent website.
+func newPreviewIngress(preview *myv1alpha1.WebsitePreview, site *myv1alpha1.Website) *networkingv1beta1.Ingress {
+	ingress := newIngress(site)
+	ingress.OwnerReferences = previewOwnerReferences(preview)
+	return ingress
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+	listers "github.com/nevermosby/my-crd-controller/pkg/client/listers/mycontroller/v1alpha1"
+)
+
+func TestValidatePreview(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    myv1alpha1.WebsitePreviewSpec
+		wantErr string
+	}{
+		{name: "branch", spec: myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "feature/new-header"}},
+		{name: "host", spec: myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "_", Host: "review.example.com"}},
+		{name: "no website", spec: myv1alpha1.WebsitePreviewSpec{Branch: "master"}, wantErr: "spec.website"},
+		{name: "no branch", spec: myv1alpha1.WebsitePreviewSpec{Website: "kubia"}, wantErr: "spec.branch"},
+		{name: "option branch", spec: myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "--upload-pack=x"}, wantErr: "branch"},
+		{name: "branch without slug", spec: myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "_"}, wantErr: "set spec.host"},
+		{name: "invalid host", spec: myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "master", Host: "Review_Host"}, wantErr: "spec.host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePreview(&myv1alpha1.WebsitePreview{Spec: tt.spec})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePreview() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePreview() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreviewConflicts(t *testing.T) {
+	uniqueIndexers := cache.Indexers{
+		websiteNameIndex:    uniqueIndexFunc(websiteNameIndex),
+		deploymentNameIndex: uniqueIndexFunc(deploymentNameIndex),
+		serviceNameIndex:    uniqueIndexFunc(serviceNameIndex),
+		hostIndex:           uniqueIndexFunc(hostIndex),
+	}
+	websites := cache.NewIndexer(cache.MetaNamespaceKeyFunc, uniqueIndexers)
+	clusterWebsites := cache.NewIndexer(cache.MetaNamespaceKeyFunc, uniqueIndexers)
+	previews := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	c := &Controller{
+		websitesIndexer:        websites,
+		clusterWebsitesIndexer: clusterWebsites,
+		previewsLister:         listers.NewWebsitePreviewLister(previews),
+	}
+
+	parent := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia"},
+		Spec: myv1alpha1.WebsiteSpec{
+			DeploymentName: "kubia",
+			GitRepo:        "https://github.com/nevermosby/kubia-website-example.git",
+			Ingress:        &myv1alpha1.WebsiteIngress{Host: "www.example.com"},
+		},
+	}
+	// a website taking the Deployment name of the preview
+	squatter := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "squatter"},
+		Spec:       myv1alpha1.WebsiteSpec{DeploymentName: "header-preview"},
+	}
+	older := metav1.NewTime(time.Now().Add(-time.Hour))
+	newer := metav1.NewTime(time.Now())
+	olderPreview := &myv1alpha1.WebsitePreview{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new-header", CreationTimestamp: older},
+		Spec:       myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "feature/new-header"},
+	}
+	for _, obj := range []interface{}{parent, squatter} {
+		if err := websites.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := previews.Add(olderPreview); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		preview *myv1alpha1.WebsitePreview
+		want    []string
+	}{
+		{
+			name: "free names",
+			preview: &myv1alpha1.WebsitePreview{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "footer", CreationTimestamp: newer},
+				Spec:       myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "feature/footer"},
+			},
+		},
+		{
+			name:    "itself",
+			preview: olderPreview,
+		},
+		{
+			name: "deployment held by a website",
+			preview: &myv1alpha1.WebsitePreview{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "header", CreationTimestamp: newer},
+				Spec:       myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "feature/footer"},
+			},
+			want: []string{
+				`deployment "header-preview" is used by Website default/squatter`,
+				`service "header-preview-npsvc" is used by Website default/squatter`,
+			},
+		},
+		{
+			name: "host of the parent",
+			preview: &myv1alpha1.WebsitePreview{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "footer", CreationTimestamp: newer},
+				Spec:       myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "feature/footer", Host: "WWW.example.com"},
+			},
+			want: []string{`host "www.example.com" is used by Website default/kubia`},
+		},
+		{
+			name: "branch of an older preview",
+			preview: &myv1alpha1.WebsitePreview{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new-header-again", CreationTimestamp: newer},
+				Spec:       myv1alpha1.WebsitePreviewSpec{Website: "kubia", Branch: "Feature/New_Header"},
+			},
+			want: []string{`host "feature-new-header.www.example.com" is used by WebsitePreview default/new-header`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.previewConflicts(tt.preview, parent, previewWebsite(tt.preview, parent))
+			if err != nil {
+				t.Fatalf("previewConflicts() error = %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("previewConflicts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
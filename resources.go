@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// resourcesAnnotation on the pod template records a hash of the
+	// resources the pods get, so changed resources are rolled out.
+	resourcesAnnotation = "mycontroller.nevermosby.io/resources"
+
+	// podIssueWindow is how long an eviction or OOM kill of a website pod
+	// keeps the PodsEvicted or OOMKilled condition true.
+	podIssueWindow = time.Hour
+
+	// evictedReason is the pod status reason of pods evicted by the kubelet.
+	evictedReason = "Evicted"
+	// oomKilledReason is the termination reason of containers killed for
+	// exceeding their memory limit.
+	oomKilledReason = "OOMKilled"
+)
+
+// Defaults of spec.resources, set with the -default-* flags.
+var (
+	defaultServerResources = corev1.ResourceRequirements{
+		Requests: mustParseResourceList("cpu=10m,memory=32Mi"),
+		Limits:   mustParseResourceList("memory=128Mi"),
+	}
+	defaultSyncResources = corev1.ResourceRequirements{
+		Requests: mustParseResourceList("cpu=10m,memory=32Mi"),
+		Limits:   mustParseResourceList("memory=256Mi"),
+	}
+	defaultContentSizeLimit = resource.MustParse("1Gi")
+)
+
+// parseResourceList parses a list like cpu=100m,memory=64Mi. An empty
+// string is an empty list.
+func parseResourceList(s string) (corev1.ResourceList, error) {
+	list := corev1.ResourceList{}
+	if s == "" {
+		return list, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("%q is not a name=quantity pair", item)
+		}
+		quantity, err := resource.ParseQuantity(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", parts[0], err)
+		}
+		list[corev1.ResourceName(parts[0])] = quantity
+	}
+	return list, nil
+}
+
+func mustParseResourceList(s string) corev1.ResourceList {
+	list, err := parseResourceList(s)
+	if err != nil {
+		panic(err)
+	}
+	return list
+}
+
+// resourceListFlag is a flag.Value setting a resource list.
+type resourceListFlag struct {
+	list *corev1.ResourceList
+}
+
+func (f resourceListFlag) String() string {
+	if f.list == nil {
+		return ""
+	}
+	names := make([]string, 0, len(*f.list))
+	for name := range *f.list {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	items := make([]string, 0, len(names))
+	for _, name := range names {
+		quantity := (*f.list)[corev1.ResourceName(name)]
+		items = append(items, name+"="+quantity.String())
+	}
+	return strings.Join(items, ",")
+}
+
+func (f resourceListFlag) Set(s string) error {
+	list, err := parseResourceList(s)
+	if err != nil {
+		return err
+	}
+	*f.list = list
+	return nil
+}
+
+// quantityFlag is a flag.Value setting a quantity.
+type quantityFlag struct {
+	quantity *resource.Quantity
+}
+
+func (f quantityFlag) String() string {
+	if f.quantity == nil {
+		return ""
+	}
+	return f.quantity.String()
+}
+
+func (f quantityFlag) Set(s string) error {
+	quantity, err := resource.ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*f.quantity = quantity
+	return nil
+}
+
+// websiteResources returns the resources of the server, sync and build
+// containers and the size limit of the content volumes. A set field of
+// spec.resources replaces the default as a whole.
+func websiteResources(website *myv1alpha1.Website) (server, sync, build corev1.ResourceRequirements, sizeLimit *resource.Quantity) {
+	server, sync = defaultServerResources, defaultSyncResources
+	if !defaultContentSizeLimit.IsZero() {
+		limit := defaultContentSizeLimit.DeepCopy()
+		sizeLimit = &limit
+	}
+	if res := website.Spec.Resources; res != nil {
+		if res.Server != nil {
+			server = *res.Server
+		}
+		if res.Sync != nil {
+			sync = *res.Sync
+		}
+		if res.ContentSizeLimit != nil {
+			limit := res.ContentSizeLimit.DeepCopy()
+			sizeLimit = &limit
+		}
+	}
+	build = sync
+	if res := website.Spec.Resources; res != nil && res.Build != nil {
+		build = *res.Build
+	}
+	return server, sync, build, sizeLimit
+}
+
+// applyResources sets the resources of every container of the pods and
+// bounds the emptyDir volumes holding the content.
+func applyResources(template *corev1.PodTemplateSpec, website *myv1alpha1.Website) {
+	server, sync, build, sizeLimit := websiteResources(website)
+	serverName := backendFor(website).Name()
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+		if container.Name == serverName {
+			container.Resources = *server.DeepCopy()
+		} else {
+			container.Resources = *sync.DeepCopy()
+		}
+	}
+	for i := range template.Spec.InitContainers {
+		container := &template.Spec.InitContainers[i]
+		if container.Name == buildContainerName {
+			container.Resources = *build.DeepCopy()
+		} else {
+			container.Resources = *sync.DeepCopy()
+		}
+	}
+	for i := range template.Spec.Volumes {
+		// A tmpfs counts against the memory limit of the containers already
+		if emptyDir := template.Spec.Volumes[i].EmptyDir; emptyDir != nil && emptyDir.Medium != corev1.StorageMediumMemory && sizeLimit != nil {
+			limit := sizeLimit.DeepCopy()
+			emptyDir.SizeLimit = &limit
+		}
+	}
+	template.Annotations[resourcesAnnotation] = hashJSON([]interface{}{server, sync, build, sizeLimit})
+}
+
+// podEvicted returns whether the kubelet evicted pod.
+func podEvicted(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == evictedReason
+}
+
+// podEvictedAt returns when pod was evicted, as near as the pod status
+// tells: the latest container termination or readiness change.
+func podEvictedAt(pod *corev1.Pod) time.Time {
+	var at time.Time
+	if pod.Status.StartTime != nil {
+		at = pod.Status.StartTime.Time
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.LastTransitionTime.After(at) {
+			at = cond.LastTransitionTime.Time
+		}
+	}
+	for _, st := range pod.Status.ContainerStatuses {
+		if t := st.State.Terminated; t != nil && t.FinishedAt.After(at) {
+			at = t.FinishedAt.Time
+		}
+	}
+	return at
+}
+
+// podOOMKilled returns the name and termination time of the container of
+// pod most recently killed for exceeding its memory limit, if any.
+func podOOMKilled(pod *corev1.Pod) (string, time.Time, bool) {
+	var name string
+	var at time.Time
+	found := false
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, st := range statuses {
+		for _, t := range []*corev1.ContainerStateTerminated{st.State.Terminated, st.LastTerminationState.Terminated} {
+			if t != nil && t.Reason == oomKilledReason && (!found || t.FinishedAt.After(at)) {
+				name, at, found = st.Name, t.FinishedAt.Time, true
+			}
+		}
+	}
+	return name, at, found
+}
+
+// syncPodHealth sets the PodsEvicted and OOMKilled conditions from the
+// website pods evicted or OOM killed within podIssueWindow, and fires a
+// warning event for each new one.
+func (c *Controller) syncPodHealth(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus) error {
+	pods, err := c.websitePods(website)
+	if err != nil {
+		return err
+	}
+	since := time.Now().Add(-podIssueWindow)
+
+	evicted, oomKilled := 0, 0
+	var evictedMsg, oomMsg string
+	var evictedAt, oomAt time.Time
+	for _, pod := range pods {
+		if pod.Labels["app"] == "website-nginx-preview" {
+			continue
+		}
+		if podEvicted(pod) {
+			if at := podEvictedAt(pod); at.After(since) {
+				evicted++
+				if at.After(evictedAt) || evictedMsg == "" {
+					evictedAt = at
+					evictedMsg = fmt.Sprintf(MessagePodEvicted, pod.Name, pod.Status.Message)
+				}
+			}
+		}
+		if container, at, ok := podOOMKilled(pod); ok && at.After(since) {
+			oomKilled++
+			if at.After(oomAt) || oomMsg == "" {
+				oomAt = at
+				oomMsg = fmt.Sprintf(MessageContainerOOMKilled, container, pod.Name)
+			}
+		}
+	}
+
+	c.setPodIssueCondition(website, status, myv1alpha1.WebsitePodsEvicted, PodEvicted, evicted, evictedMsg)
+	c.setPodIssueCondition(website, status, myv1alpha1.WebsiteOOMKilled, ContainerOOMKilled, oomKilled, oomMsg)
+	return nil
+}
+
+// setPodIssueCondition sets condType true with the latest of count issues,
+// or false when there are none. A new latest issue fires a warning event.
+func (c *Controller) setPodIssueCondition(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, condType myv1alpha1.WebsiteConditionType, reason string, count int, latest string) {
+	if count == 0 {
+		setWebsiteCondition(status, newWebsiteCondition(condType, corev1.ConditionFalse, reason, ""))
+		return
+	}
+	if cond := getWebsiteCondition(*status, condType); cond == nil || cond.Status != corev1.ConditionTrue || !strings.HasSuffix(cond.Message, latest) {
+		c.recorder.Event(website, corev1.EventTypeWarning, reason, latest)
+	}
+	msg := latest
+	if count > 1 {
+		msg = fmt.Sprintf("%d times recently, latest: %s", count, latest)
+	}
+	setWebsiteCondition(status, newWebsiteCondition(condType, corev1.ConditionTrue, reason, msg))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// lsRemoteTimeout bounds a single ls-remote call.
+	lsRemoteTimeout = 30 * time.Second
+
+	// minResolveInterval rate limits resolutions requested by push webhooks.
+	minResolveInterval = 10 * time.Second
+)
+
+// revisionResolver resolves a branch of a git repository to a commit SHA.
+type revisionResolver interface {
+	Resolve(ctx context.Context, repo, branch string) (string, error)
+}
+
+// lsRemoteResolver resolves revisions with `git ls-remote`, so it works the
+// same against remote hosts, file:// URLs and local bare repositories.
+type lsRemoteResolver struct {
+	// git is the path of the git binary, defaults to git in $PATH.
+	git string
+}
+
+func (r *lsRemoteResolver) Resolve(ctx context.Context, repo, branch string) (string, error) {
+	git := r.git
+	if git == "" {
+		git = "git"
+	}
+	ref := "refs/heads/" + branch
+	// -- keeps a repository starting with - from being read as an option
+	cmd := exec.CommandContext(ctx, git, "ls-remote", "--exit-code", "--", repo, ref)
+	// never block on a credential prompt
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 2 {
+			return "", fmt.Errorf("branch %q not found in %s", branch, repo)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("ls-remote %s: %s", repo, msg)
+		}
+		return "", fmt.Errorf("ls-remote %s: %v", repo, err)
+	}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == ref {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("branch %q not found in %s", branch, repo)
+}
+
+// resolve resolves branch of repo in the background and returns the commit
+// SHA and true once resolved; done is called when it is. Websites and
+// sources following the same branch share the resolution. A resolution is
+// kept for minResolveInterval, which a sync waits for before resolving again.
+func (c *Controller) resolve(repo, branch string, done func()) (string, bool, error) {
+	revision, ok, err := c.gitTasks.Do("ls-remote "+repo+"#"+branch, minResolveInterval, func(ctx context.Context) (interface{}, error) {
+		return c.resolver.Resolve(ctx, repo, branch)
+	}, done)
+	if !ok || err != nil {
+		return "", ok, err
+	}
+	return revision.(string), true, nil
+}
+
+// websiteRef returns the repository and branch a Website follows, in the
+// form recorded in status.resolvedRef.
+func websiteRef(website *myv1alpha1.Website) string {
+	return websiteRepo(website) + "#" + websiteBranch(website)
+}
+
+// revisionDue tells whether the branch followed by the website has to be
+// resolved again: it was never resolved, the followed ref changed, the poll
+// interval elapsed or a push webhook announced a different commit. A push
+// arriving within minResolveInterval of the last resolution requeues the
+// website for when the interval is over, rather than for the next poll.
+func (c *Controller) revisionDue(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus) bool {
+	if status.LastResolveTime == nil || status.ResolvedRef != websiteRef(website) {
+		return true
+	}
+	since := time.Since(status.LastResolveTime.Time)
+	if pushed := website.Annotations[syncRequestAnnotation]; pushed != "" && pushed != status.LatestRevision {
+		if since >= minResolveInterval {
+			return true
+		}
+		c.enqueueWebsiteAfter(website, minResolveInterval-since)
+	}
+	return since >= c.gitPollInterval
+}
+
+// syncRevision resolves the branch followed by the website to a commit SHA
+// when due, and records the result and any resolution error in status.
+// The resolution runs in the background, the website is synced again once it
+// returned. Sources other than git have no revisions.
+func (c *Controller) syncRevision(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus) {
+	if websiteGitSource(website) == nil {
+		status.LatestRevision = ""
+		status.ResolvedRef = ""
+		status.LastResolveTime = nil
+		removeWebsiteCondition(status, myv1alpha1.WebsiteRevisionResolved)
+		return
+	}
+	if !c.revisionDue(website, status) {
+		return
+	}
+	ref := websiteRef(website)
+	branch := websiteBranch(website)
+
+	revision, ok, err := c.resolve(websiteRepo(website), branch, func() { c.enqueueWebsiteAfter(website, 0) })
+	if !ok {
+		return
+	}
+	now := metav1.Now()
+	status.LastResolveTime = &now
+	if err != nil {
+		if status.ResolvedRef != ref {
+			// the old revision belongs to another repository or branch
+			status.LatestRevision = ""
+			status.ResolvedRef = ref
+		}
+		setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteRevisionResolved, corev1.ConditionFalse, ErrResolveRevision, err.Error()))
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrResolveRevision, err.Error())
+		return
+	}
+
+	if revision != status.LatestRevision {
+		klog.Infof("Website %s/%s branch %s resolved to %s", website.Namespace, website.Name, branch, revision)
+		c.recorder.Eventf(website, corev1.EventTypeNormal, RevisionResolved, MessageRevisionResolved, branch, revision)
+	}
+	status.LatestRevision = revision
+	status.ResolvedRef = ref
+	setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteRevisionResolved, corev1.ConditionTrue, RevisionResolved, fmt.Sprintf(MessageRevisionResolved, branch, revision)))
+}
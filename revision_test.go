@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// tempDir creates a temporary directory removed by the returned func.
+func tempDir(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "my-crd-controller")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// newBareRepo creates a bare repository with one commit on master in dir,
+// holding index.html and docs/index.html, and returns its path and the
+// commit SHA.
+func newBareRepo(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	work := filepath.Join(dir, "work")
+	bare := filepath.Join(dir, "site.git")
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", work)
+	git("-C", work, "symbolic-ref", "HEAD", "refs/heads/master")
+	if err := os.Mkdir(filepath.Join(work, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"index.html", "docs/index.html"} {
+		if err := ioutil.WriteFile(filepath.Join(work, name), []byte("<h1>kubia</h1>\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("-C", work, "add", ".")
+	git("-C", work, "commit", "-q", "-m", "initial")
+	git("clone", "-q", "--bare", work, bare)
+	return bare, git("-C", work, "rev-parse", "HEAD")
+}
+
+func TestLsRemoteResolver(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	bare, sha := newBareRepo(t, dir)
+	resolver := &lsRemoteResolver{}
+
+	tests := []struct {
+		name    string
+		repo    string
+		branch  string
+		want    string
+		wantErr string
+	}{
+		{name: "bare repository path", repo: bare, branch: "master", want: sha},
+		{name: "file URL", repo: "file://" + bare, branch: "master", want: sha},
+		{name: "missing branch", repo: bare, branch: "gh-pages", wantErr: `branch "gh-pages" not found`},
+		{name: "missing repository", repo: filepath.Join(bare, "missing"), branch: "master", wantErr: "ls-remote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(context.Background(), tt.repo, tt.branch)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLsRemoteResolverRepoIsNotAnOption(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	pwned := filepath.Join(dir, "pwned")
+	repo := "--upload-pack=touch " + pwned + ";"
+	if _, err := (&lsRemoteResolver{}).Resolve(context.Background(), repo, "master"); err == nil {
+		t.Fatal("Resolve() of an option succeeded")
+	}
+	if _, err := os.Stat(pwned); err == nil {
+		t.Fatal("the repository was run as --upload-pack")
+	}
+}
+
+func TestValidateGitSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		git     myv1alpha1.GitSource
+		wantErr bool
+	}{
+		{name: "https", git: myv1alpha1.GitSource{Repo: "https://github.com/nevermosby/kubia-website-example.git", Branch: "master"}},
+		{name: "scp-like", git: myv1alpha1.GitSource{Repo: "git@github.com:nevermosby/kubia-website-example.git"}},
+		{name: "nested branch", git: myv1alpha1.GitSource{Repo: "/srv/git/site.git", Branch: "feature/docs"}},
+		{name: "empty repository", git: myv1alpha1.GitSource{}, wantErr: true},
+		{name: "option repository", git: myv1alpha1.GitSource{Repo: "--upload-pack=touch /tmp/pwned"}, wantErr: true},
+		{name: "option branch", git: myv1alpha1.GitSource{Repo: "/srv/git/site.git", Branch: "--orphan"}, wantErr: true},
+		{name: "branch with newline", git: myv1alpha1.GitSource{Repo: "/srv/git/site.git", Branch: "main\n"}, wantErr: true},
+		{name: "branch with range", git: myv1alpha1.GitSource{Repo: "/srv/git/site.git", Branch: "a..b"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateGitSource(&tt.git); (err != nil) != tt.wantErr {
+				t.Errorf("validateGitSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// blockingResolver resolves every branch to revision once release is
+// closed.
+type blockingResolver struct {
+	revision string
+	release  chan struct{}
+}
+
+func (r *blockingResolver) Resolve(ctx context.Context, repo, branch string) (string, error) {
+	<-r.release
+	return r.revision, nil
+}
+
+func TestSyncRevisionResolvesInBackground(t *testing.T) {
+	resolver := &blockingResolver{revision: "0123456789abcdef0123456789abcdef01234567", release: make(chan struct{})}
+	c := &Controller{
+		workqueue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Websites"),
+		recorder:        record.NewFakeRecorder(10),
+		resolver:        resolver,
+		gitTasks:        newGitTasks(),
+		gitPollInterval: time.Minute,
+	}
+	defer c.workqueue.ShutDown()
+	website := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia"},
+		Spec:       myv1alpha1.WebsiteSpec{GitRepo: "https://github.com/nevermosby/kubia-website-example.git"},
+	}
+	status := &myv1alpha1.WebsiteStatus{}
+
+	c.syncRevision(website, status)
+	if status.LatestRevision != "" || status.LastResolveTime != nil {
+		t.Fatalf("syncRevision() waited for the resolution: %+v", status)
+	}
+
+	close(resolver.release)
+	requeued := make(chan interface{})
+	go func() {
+		key, _ := c.workqueue.Get()
+		requeued <- key
+	}()
+	select {
+	case key := <-requeued:
+		if key != "default/kubia" {
+			t.Fatalf("requeued %v, want default/kubia", key)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the website was not requeued once resolved")
+	}
+
+	c.syncRevision(website, status)
+	if status.LatestRevision != resolver.revision {
+		t.Errorf("LatestRevision = %q, want %q", status.LatestRevision, resolver.revision)
+	}
+}
+
+func TestRevisionDueRequeuesPushWithinMinResolveInterval(t *testing.T) {
+	c := &Controller{
+		workqueue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Websites"),
+		gitPollInterval: time.Hour,
+	}
+	defer c.workqueue.ShutDown()
+	website := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "kubia",
+			Annotations: map[string]string{syncRequestAnnotation: "1111111111111111111111111111111111111111"},
+		},
+		Spec: myv1alpha1.WebsiteSpec{GitRepo: "https://github.com/nevermosby/kubia-website-example.git"},
+	}
+	resolved := metav1.NewTime(time.Now().Add(-minResolveInterval + 200*time.Millisecond))
+	status := &myv1alpha1.WebsiteStatus{
+		LatestRevision:  "0123456789abcdef0123456789abcdef01234567",
+		ResolvedRef:     websiteRef(website),
+		LastResolveTime: &resolved,
+	}
+
+	if c.revisionDue(website, status) {
+		t.Fatal("revisionDue() = true within minResolveInterval")
+	}
+	requeued := make(chan interface{})
+	go func() {
+		key, _ := c.workqueue.Get()
+		requeued <- key
+	}()
+	select {
+	case key := <-requeued:
+		if key != "default/kubia" {
+			t.Fatalf("requeued %v, want default/kubia", key)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the push was not requeued once minResolveInterval is over")
+	}
+
+	status.LastResolveTime = &metav1.Time{Time: time.Now().Add(-minResolveInterval)}
+	if !c.revisionDue(website, status) {
+		t.Error("revisionDue() = false for a push after minResolveInterval")
+	}
+}
+
+func TestSyncedChangedOnlyOnNewSpecOrRevision(t *testing.T) {
+	c := &Controller{}
+	website := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia"},
+		Spec:       myv1alpha1.WebsiteSpec{DeploymentName: "kubia", GitRepo: "https://github.com/nevermosby/kubia-website-example.git"},
+	}
+	scaled := website.DeepCopy()
+	replicas := int32(3)
+	scaled.Spec.Replicas = &replicas
+
+	for _, tt := range []struct {
+		name     string
+		website  *myv1alpha1.Website
+		revision string
+		want     bool
+	}{
+		{name: "first sync", website: website, revision: "1111111111111111111111111111111111111111", want: true},
+		{name: "poll resync", website: website, revision: "1111111111111111111111111111111111111111"},
+		{name: "new revision", website: website, revision: "2222222222222222222222222222222222222222", want: true},
+		{name: "new spec", website: scaled, revision: "2222222222222222222222222222222222222222", want: true},
+		{name: "poll resync of the new spec", website: scaled, revision: "2222222222222222222222222222222222222222"},
+	} {
+		if got := c.syncedChanged("default/kubia", tt.website, tt.revision); got != tt.want {
+			t.Errorf("%s: syncedChanged() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	c.forgetSynced("default/kubia")
+	if !c.syncedChanged("default/kubia", scaled, "2222222222222222222222222222222222222222") {
+		t.Errorf("syncedChanged() of a recreated website = false, want true")
+	}
+}
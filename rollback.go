@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// revisionHistoryLimit bounds status.history of a Website.
+	revisionHistoryLimit = 10
+
+	// minRevisionPrefix is the shortest abbreviated SHA accepted in
+	// spec.rollbackTo.
+	minRevisionPrefix = 7
+
+	// values of WebsiteRevision.TriggeredBy
+	triggeredByPoll     = "poll"
+	triggeredByWebhook  = "webhook"
+	triggeredByRollback = "rollback"
+)
+
+// targetRevision returns the commit the site pods should serve and what
+// asked for it: the pinned revision from spec.rollbackTo, or the branch head.
+func (c *Controller) targetRevision(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus) (string, string) {
+	if website.Spec.RollbackTo == "" {
+		if getWebsiteCondition(*status, myv1alpha1.WebsiteRolledBack) != nil {
+			removeWebsiteCondition(status, myv1alpha1.WebsiteRolledBack)
+			c.recorder.Eventf(website, corev1.EventTypeNormal, RollbackCleared, MessageRollbackCleared, websiteBranch(website), status.LatestRevision)
+		}
+		if status.LatestRevision != "" && website.Annotations[syncRequestAnnotation] == status.LatestRevision {
+			return status.LatestRevision, triggeredByWebhook
+		}
+		return status.LatestRevision, triggeredByPoll
+	}
+
+	entry := findRevision(status.History, website.Spec.RollbackTo)
+	if entry == nil {
+		// Keep serving the current revision rather than guessing.
+		msg := fmt.Sprintf(MessageRollbackNotFound, website.Spec.RollbackTo)
+		if cond := getWebsiteCondition(*status, myv1alpha1.WebsiteRolledBack); cond == nil || cond.Message != msg {
+			c.recorder.Event(website, corev1.EventTypeWarning, ErrRollbackRevision, msg)
+		}
+		setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteRolledBack, corev1.ConditionFalse, ErrRollbackRevision, msg))
+		return status.Revision, triggeredByRollback
+	}
+	cond := getWebsiteCondition(*status, myv1alpha1.WebsiteRolledBack)
+	if status.Revision != entry.Revision || cond == nil || cond.Status != corev1.ConditionTrue {
+		setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteRolledBack, corev1.ConditionTrue, RollingBack, fmt.Sprintf(MessageRollingBack, entry.Revision)))
+	}
+	return entry.Revision, triggeredByRollback
+}
+
+// recordRevision records revision as the one served by the site pods and
+// adds it to the bounded revision history.
+func (c *Controller) recordRevision(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, revision, triggeredBy string) {
+	if revision == status.Revision {
+		return
+	}
+	if triggeredBy == triggeredByRollback {
+		c.recorder.Eventf(website, corev1.EventTypeNormal, RollingBack, MessageRollingBackFrom, status.Revision, revision)
+	}
+	status.Revision = revision
+	if revision == "" {
+		return
+	}
+	entry := myv1alpha1.WebsiteRevision{
+		Revision:    revision,
+		Time:        metav1.Now(),
+		TriggeredBy: triggeredBy,
+	}
+	status.History = append([]myv1alpha1.WebsiteRevision{entry}, status.History...)
+	if len(status.History) > revisionHistoryLimit {
+		status.History = status.History[:revisionHistoryLimit]
+	}
+}
+
+// syncRollbackStatus marks a rollback as done once the Deployment finished
+// rolling out the pinned revision.
+func (c *Controller) syncRollbackStatus(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, deployment *appsv1.Deployment) {
+	cond := getWebsiteCondition(*status, myv1alpha1.WebsiteRolledBack)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != RollingBack {
+		return
+	}
+	if deployment == nil || deployment.Spec.Template.Annotations[revisionAnnotation] != status.Revision || !deploymentComplete(deployment) {
+		return
+	}
+	msg := fmt.Sprintf(MessageRolledBack, status.Revision)
+	setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteRolledBack, corev1.ConditionTrue, RolledBack, msg))
+	c.recorder.Event(website, corev1.EventTypeNormal, RolledBack, msg)
+}
+
+// findRevision looks revision up in history. Abbreviated SHAs of at least
+// minRevisionPrefix characters are accepted.
+func findRevision(history []myv1alpha1.WebsiteRevision, revision string) *myv1alpha1.WebsiteRevision {
+	for i := range history {
+		if history[i].Revision == revision {
+			return &history[i]
+		}
+	}
+	if len(revision) < minRevisionPrefix {
+		return nil
+	}
+	for i := range history {
+		if strings.HasPrefix(history[i].Revision, revision) {
+			return &history[i]
+		}
+	}
+	return nil
+}
+
+// deploymentComplete tells whether the Deployment rolled out its latest pod
+// template to all replicas and all of them are available.
+func deploymentComplete(deployment *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.Replicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas
+}
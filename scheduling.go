@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// hostnameTopologyKey is the node label the default anti-affinity
+	// spreads replicas over.
+	hostnameTopologyKey = "kubernetes.io/hostname"
+
+	// defaultServiceAccountName is the service account the API server sets
+	// on pods that name none.
+	defaultServiceAccountName = "default"
+)
+
+// validateScheduling returns an error when spec.antiAffinity is not a
+// known mode.
+func validateScheduling(website *myv1alpha1.Website) error {
+	switch website.Spec.AntiAffinity {
+	case "", myv1alpha1.AntiAffinityPreferred, myv1alpha1.AntiAffinityRequired:
+		return nil
+	}
+	return fmt.Errorf("spec.antiAffinity: unknown mode %q, expected %s or %s", website.Spec.AntiAffinity, myv1alpha1.AntiAffinityPreferred, myv1alpha1.AntiAffinityRequired)
+}
+
+// applyScheduling copies the scheduling fields of the website onto the pod
+// template, adding the opt-in anti-affinity across replicas.
+func applyScheduling(template *corev1.PodTemplateSpec, website *myv1alpha1.Website) {
+	spec := &template.Spec
+	if website.Spec.NodeSelector != nil {
+		spec.NodeSelector = make(map[string]string, len(website.Spec.NodeSelector))
+		for key, value := range website.Spec.NodeSelector {
+			spec.NodeSelector[key] = value
+		}
+	}
+	for _, toleration := range website.Spec.Tolerations {
+		spec.Tolerations = append(spec.Tolerations, *toleration.DeepCopy())
+	}
+	spec.Affinity = website.Spec.Affinity.DeepCopy()
+	for _, constraint := range website.Spec.TopologySpreadConstraints {
+		spec.TopologySpreadConstraints = append(spec.TopologySpreadConstraints, *constraint.DeepCopy())
+	}
+	spec.PriorityClassName = website.Spec.PriorityClassName
+	spec.ServiceAccountName = website.Spec.ServiceAccountName
+
+	mode := website.Spec.AntiAffinity
+	if mode == "" || (spec.Affinity != nil && spec.Affinity.PodAntiAffinity != nil) {
+		return
+	}
+	term := corev1.PodAffinityTerm{
+		LabelSelector: websitePodSelector(website),
+		TopologyKey:   hostnameTopologyKey,
+	}
+	antiAffinity := &corev1.PodAntiAffinity{}
+	if mode == myv1alpha1.AntiAffinityRequired {
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = []corev1.PodAffinityTerm{term}
+	} else {
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = []corev1.WeightedPodAffinityTerm{
+			{Weight: 100, PodAffinityTerm: term},
+		}
+	}
+	if spec.Affinity == nil {
+		spec.Affinity = &corev1.Affinity{}
+	}
+	spec.Affinity.PodAntiAffinity = antiAffinity
+}
+
+// schedulingDrifted returns whether the scheduling fields of the current pod
+// spec differ from the desired ones, whether the website changed them or
+// the Deployment was edited by hand.
+func schedulingDrifted(desired, current *corev1.PodSpec) bool {
+	serviceAccount := func(spec *corev1.PodSpec) string {
+		if spec.ServiceAccountName == "" {
+			return defaultServiceAccountName
+		}
+		return spec.ServiceAccountName
+	}
+	return !equality.Semantic.DeepEqual(desired.NodeSelector, current.NodeSelector) ||
+		!equality.Semantic.DeepEqual(desired.Tolerations, current.Tolerations) ||
+		!equality.Semantic.DeepEqual(desired.Affinity, current.Affinity) ||
+		!equality.Semantic.DeepEqual(desired.TopologySpreadConstraints, current.TopologySpreadConstraints) ||
+		desired.PriorityClassName != current.PriorityClassName ||
+		serviceAccount(desired) != serviceAccount(current)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// hardenedUser is the user and group hardened pods run as, the nginx
+	// user of the unprivileged nginx image.
+	hardenedUser = 101
+
+	// httpPort and hardenedHTTPPort are the ports the server listens on,
+	// the latter unprivileged. The Service targets them by httpPortName.
+	httpPort         = 80
+	hardenedHTTPPort = 8080
+	httpPortName     = "http"
+
+	// seccompPodAnnotation sets the seccomp profile of all containers of a
+	// pod, the field only exists from Kubernetes 1.19.
+	seccompPodAnnotation  = "seccomp.security.alpha.kubernetes.io/pod"
+	seccompRuntimeDefault = "runtime/default"
+
+	// tmpVolumeName is the tmpfs mounted where hardened containers write.
+	tmpVolumeName = "tmp"
+	tmpDir        = "/tmp"
+)
+
+// defaultPodSecurity is the pod security of websites without
+// spec.podSecurity, set with the -default-pod-security flag.
+var defaultPodSecurity = string(myv1alpha1.PodSecurityHardened)
+
+// validatePodSecurityMode returns an error when mode is not a known pod
+// security mode, naming field.
+func validatePodSecurityMode(field string, mode myv1alpha1.PodSecurityMode) error {
+	switch mode {
+	case myv1alpha1.PodSecurityHardened, myv1alpha1.PodSecurityUnrestricted:
+		return nil
+	}
+	return fmt.Errorf("%s: unknown mode %q, expected %s or %s", field, mode, myv1alpha1.PodSecurityHardened, myv1alpha1.PodSecurityUnrestricted)
+}
+
+// validatePodSecurity returns an error when spec.podSecurity is set to an
+// unknown mode.
+func validatePodSecurity(website *myv1alpha1.Website) error {
+	if website.Spec.PodSecurity == "" {
+		return nil
+	}
+	return validatePodSecurityMode("spec.podSecurity", website.Spec.PodSecurity)
+}
+
+// websiteHardened tells whether the website pods run hardened.
+func websiteHardened(website *myv1alpha1.Website) bool {
+	mode := website.Spec.PodSecurity
+	if mode == "" {
+		mode = myv1alpha1.PodSecurityMode(defaultPodSecurity)
+	}
+	return mode == myv1alpha1.PodSecurityHardened
+}
+
+// serverPort returns the port the server of the website listens on.
+func serverPort(website *myv1alpha1.Website) int32 {
+	if websiteHardened(website) {
+		return hardenedHTTPPort
+	}
+	return httpPort
+}
+
+// serverContainerPort is the named port of the serving container.
+func serverContainerPort(website *myv1alpha1.Website) corev1.ContainerPort {
+	return corev1.ContainerPort{
+		Name:          httpPortName,
+		ContainerPort: serverPort(website),
+		Protocol:      corev1.ProtocolTCP,
+	}
+}
+
+// applySecurity locks the pods of a hardened website down. Every container
+// runs as hardenedUser without capabilities or privilege escalation, and
+// with a read-only root filesystem, except the build container running an
+// arbitrary builder image. A tmpfs is mounted at /tmp and at the
+// directories the server writes to.
+func applySecurity(template *corev1.PodTemplateSpec, website *myv1alpha1.Website) {
+	if !websiteHardened(website) {
+		return
+	}
+	runAsNonRoot := true
+	user := int64(hardenedUser)
+	template.Spec.SecurityContext = &corev1.PodSecurityContext{
+		RunAsNonRoot: &runAsNonRoot,
+		RunAsUser:    &user,
+		RunAsGroup:   &user,
+		FSGroup:      &user,
+	}
+	template.Annotations[seccompPodAnnotation] = seccompRuntimeDefault
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: tmpVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+		},
+	})
+
+	backend := backendFor(website)
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+		hardenContainer(container, true)
+		if container.Name != backend.Name() {
+			container.Env = append(container.Env, corev1.EnvVar{Name: "HOME", Value: tmpDir})
+			continue
+		}
+		for _, dir := range backend.WritableDirs() {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      tmpVolumeName,
+				MountPath: dir,
+				SubPath:   strings.Trim(strings.Replace(dir, "/", "-", -1), "-"),
+			})
+		}
+	}
+	for i := range template.Spec.InitContainers {
+		container := &template.Spec.InitContainers[i]
+		hardenContainer(container, container.Name != buildContainerName)
+		container.Env = append(container.Env, corev1.EnvVar{Name: "HOME", Value: tmpDir})
+	}
+}
+
+// hardenContainer sets the security context of a hardened container and
+// mounts the tmpfs at /tmp.
+func hardenContainer(container *corev1.Container, readOnlyRoot bool) {
+	allowPrivilegeEscalation := false
+	container.SecurityContext = &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		ReadOnlyRootFilesystem:   &readOnlyRoot,
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      tmpVolumeName,
+		MountPath: tmpDir,
+		SubPath:   tmpVolumeName,
+	})
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// configHashAnnotation on the pod template records a hash of the
+	// rendered server configuration, so a changed configuration rolls the
+	// pods.
+	configHashAnnotation = "mycontroller.nevermosby.io/config-hash"
+)
+
+var (
+	// headerNamePattern matches valid HTTP header names.
+	headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+	// locationPattern matches the path prefixes headers may be set on.
+	locationPattern = regexp.MustCompile(`^/[A-Za-z0-9._/-]*$`)
+)
+
+// validateServer checks the parts of spec.server rendered into the server
+// configuration.
+func validateServer(server *myv1alpha1.WebsiteServer) error {
+	if server == nil {
+		return nil
+	}
+	if server.Type != "" {
+		if _, ok := serverBackends[server.Type]; !ok {
+			return fmt.Errorf("server.type %q must be nginx, caddy or httpd", server.Type)
+		}
+	}
+	for i, redirect := range server.Redirects {
+		if redirect.From == "" || redirect.To == "" {
+			return fmt.Errorf("server.redirects[%d]: from and to are required", i)
+		}
+		if redirect.Code != 0 && redirect.Code != 301 && redirect.Code != 302 {
+			return fmt.Errorf("server.redirects[%d]: code must be 301 or 302", i)
+		}
+		if hasControlChars(redirect.From) || hasControlChars(redirect.To) {
+			return fmt.Errorf("server.redirects[%d]: from and to must not contain control characters", i)
+		}
+	}
+	for i, headers := range server.Headers {
+		if headers.Path != "" && !locationPattern.MatchString(headers.Path) {
+			return fmt.Errorf("server.headers[%d]: path %q must be an absolute path of letters, digits, '.', '_', '-' and '/'", i, headers.Path)
+		}
+		for name, value := range headers.Headers {
+			if !headerNamePattern.MatchString(name) {
+				return fmt.Errorf("server.headers[%d]: invalid header name %q", i, name)
+			}
+			if hasControlChars(value) {
+				return fmt.Errorf("server.headers[%d]: value of %s must not contain control characters", i, name)
+			}
+		}
+	}
+	if server.NotFoundPage != "" && !locationPattern.MatchString(server.NotFoundPage) {
+		return fmt.Errorf("server.notFoundPage %q must be an absolute path of letters, digits, '.', '_', '-' and '/'", server.NotFoundPage)
+	}
+	return nil
+}
+
+func hasControlChars(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0
+}
+
+// quoteString quotes s as a double quoted string, as understood by all
+// server backends.
+func quoteString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// serverConfigManaged tells whether the controller renders the server
+// configuration of the website instead of using the stock one. Hardened
+// pods need it to listen on an unprivileged port.
+func serverConfigManaged(website *myv1alpha1.Website) bool {
+	return website.Spec.Server != nil || website.Spec.Auth != nil || websiteHardened(website)
+}
+
+// configName returns the name of the ConfigMap holding the server
+// configuration of the website. The suffix predates other backends than
+// nginx, and is kept so existing ConfigMaps are updated in place.
+func configName(website *myv1alpha1.Website) string {
+	return website.Spec.DeploymentName + "-nginx"
+}
+
+// configHash returns the value of configHashAnnotation for a ConfigMap.
+func configHash(configMap *corev1.ConfigMap) string {
+	keys := make([]string, 0, len(configMap.Data))
+	for key := range configMap.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s\x00%s\x00", key, configMap.Data[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:10]
+}
+
+// newConfigMap creates the ConfigMap holding the rendered server
+// configuration of the website.
+func newConfigMap(website *myv1alpha1.Website) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configName(website),
+			Namespace: website.Namespace,
+			Labels: map[string]string{
+				"app":        "website",
+				"controller": website.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*websiteControllerRef(website),
+			},
+		},
+		Data: map[string]string{
+			backendFor(website).ConfigKey(): backendFor(website).RenderConfig(website),
+		},
+	}
+}
+
+// applyServerConfig mounts the rendered server configuration over the stock
+// one of the serving container, along with the htpasswd file it refers to.
+func applyServerConfig(template *corev1.PodTemplateSpec, website *myv1alpha1.Website) {
+	if website.Spec.Auth != nil {
+		applyAuth(template, website)
+	}
+	configMap := newConfigMap(website)
+	template.Annotations[configHashAnnotation] = configHash(configMap)
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: "server-config",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+			},
+		},
+	})
+	if container := servingContainer(template, website); container != nil {
+		mount := backendFor(website).ConfigMount()
+		mount.Name = "server-config"
+		mount.ReadOnly = true
+		container.VolumeMounts = append(container.VolumeMounts, mount)
+	}
+}
+
+// syncServerConfig creates or updates the ConfigMap of the server
+// configuration, and deletes it once spec.server and spec.auth are unset.
+func (c *Controller) syncServerConfig(website *myv1alpha1.Website) error {
+	if !serverConfigManaged(website) {
+		return c.deleteConfigMap(website, configName(website))
+	}
+	_, err := c.syncConfigMap(website, newConfigMap(website))
+	return err
+}
+
+// syncConfigMap creates the desired ConfigMap, or updates the existing one
+// when its data drifted.
+func (c *Controller) syncConfigMap(owner ownerObject, desired *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	configMap, err := c.configMapsLister.ConfigMaps(desired.Namespace).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		return c.kubeclientset.CoreV1().ConfigMaps(desired.Namespace).Create(desired)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(configMap, owner) {
+		msg := fmt.Sprintf(MessageResourceExists, configMap.Name)
+		c.recorder.Event(owner, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return nil, fmt.Errorf(msg)
+	}
+	if equality.Semantic.DeepEqual(configMap.Data, desired.Data) {
+		return configMap, nil
+	}
+	klog.V(4).Infof("ConfigMap %s/%s drifted, updating", desired.Namespace, desired.Name)
+	configMapCopy := configMap.DeepCopy()
+	configMapCopy.Data = desired.Data
+	return c.kubeclientset.CoreV1().ConfigMaps(desired.Namespace).Update(configMapCopy)
+}
+
+// deleteConfigMap deletes the named ConfigMap if the owner controls it.
+func (c *Controller) deleteConfigMap(owner ownerObject, name string) error {
+	configMap, err := c.configMapsLister.ConfigMaps(owner.GetNamespace()).Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(configMap, owner) {
+		return nil
+	}
+	err = c.kubeclientset.CoreV1().ConfigMaps(owner.GetNamespace()).Delete(name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,681 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// sourceAnnotation on the pod template records a hash of a source
+	// without revisions, so a changed URL or reference rolls the pods. Git
+	// sources roll with revisionAnnotation instead.
+	sourceAnnotation = "mycontroller.nevermosby.io/source"
+
+	// sourceConfigMapDir is where a ConfigMap source is mounted to be
+	// copied into the checkout of a build.
+	sourceConfigMapDir = "/configmap"
+
+	// sourcesAnnotation on the pod template records a hash of the sources
+	// served next to the primary one, with the revisions their git sources
+	// are pinned to.
+	sourcesAnnotation = "mycontroller.nevermosby.io/sources"
+
+	// s3SyncInterval is the number of seconds between two syncs of an S3
+	// source by its sidecar.
+	s3SyncInterval = 60
+)
+
+// cloneScript checks the revision out into $DEST. Unlike the git-sync
+// sidecar it runs once, since every revision is a new rollout.
+const cloneScript = `git clone --quiet --branch "$GIT_BRANCH" -- "$GIT_REPO" "$DEST" &&
+if [ -n "$GIT_REV" ]; then git -C "$DEST" checkout --quiet "$GIT_REV"; fi`
+
+// downloadScript downloads the archive of an HTTP source, checks it and
+// extracts it into $DEST.
+const downloadScript = `wget -q -O /tmp/archive "$SOURCE_URL" &&
+echo "$SOURCE_SHA256  /tmp/archive" | sha256sum -c >/dev/null &&
+case "${SOURCE_URL%%\?*}" in
+*.zip) unzip -q -o /tmp/archive -d "$DEST" ;;
+*.tar.gz|*.tgz) tar -xzf /tmp/archive -C "$DEST" ;;
+*) tar -xf /tmp/archive -C "$DEST" ;;
+esac`
+
+// s3SyncScript mirrors the bucket prefix of an S3 source into $DEST.
+const s3SyncScript = `if [ -z "$AWS_ACCESS_KEY_ID" ]; then set -- --no-sign-request; fi
+aws s3 sync --only-show-errors --delete --endpoint-url "$S3_ENDPOINT" "$@" "s3://$S3_BUCKET/$S3_PREFIX" "$DEST"`
+
+// copyConfigMapScript copies the keys of a mounted ConfigMap into $DEST,
+// leaving out the hidden directories kubelet updates them through.
+const copyConfigMapScript = `for f in ` + sourceConfigMapDir + `/*; do cp -L "$f" "$DEST/"; done`
+
+var (
+	// sha256Pattern matches a hex encoded SHA-256 checksum.
+	sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	// archivePattern matches the paths of the archives an HTTP source
+	// can extract.
+	archivePattern = regexp.MustCompile(`\.(tar|tar\.gz|tgz|zip)$`)
+	// sourceNamePattern matches source names, which end up in volume and
+	// container names.
+	sourceNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,38}[a-z0-9])?$`)
+)
+
+// websiteSources returns the sources of the website, the primary one
+// first. gitRepo and source are a single source mounted at /.
+func websiteSources(website *myv1alpha1.Website) []myv1alpha1.WebsiteSource {
+	if len(website.Spec.Sources) > 0 {
+		return website.Spec.Sources
+	}
+	return []myv1alpha1.WebsiteSource{websiteSource(website)}
+}
+
+// websiteSource returns the primary source of the website, with gitRepo and
+// branch expanded to a git source.
+func websiteSource(website *myv1alpha1.Website) myv1alpha1.WebsiteSource {
+	if len(website.Spec.Sources) > 0 {
+		return website.Spec.Sources[0]
+	}
+	if website.Spec.Source != nil {
+		return *website.Spec.Source
+	}
+	return myv1alpha1.WebsiteSource{
+		Git: &myv1alpha1.GitSource{Repo: website.Spec.GitRepo, Branch: website.Spec.Branch},
+	}
+}
+
+// websiteGitSource returns the git source of the website, nil when the
+// content comes from elsewhere.
+func websiteGitSource(website *myv1alpha1.Website) *myv1alpha1.GitSource {
+	return websiteSource(website).Git
+}
+
+// websiteRepo returns the git repository of the website, empty when the
+// content comes from elsewhere.
+func websiteRepo(website *myv1alpha1.Website) string {
+	if git := websiteGitSource(website); git != nil {
+		return git.Repo
+	}
+	return ""
+}
+
+// gitBranch returns the branch of a git source, defaultBranch when unset.
+func gitBranch(git *myv1alpha1.GitSource) string {
+	if git.Branch != "" {
+		return git.Branch
+	}
+	return defaultBranch
+}
+
+// sourceMountPath returns the mount path of a source, / when unset.
+func sourceMountPath(source myv1alpha1.WebsiteSource) string {
+	if source.MountPath == "" {
+		return "/"
+	}
+	return source.MountPath
+}
+
+// sourceType names the member set in a source.
+func sourceType(source myv1alpha1.WebsiteSource) string {
+	switch {
+	case source.Git != nil:
+		return "git"
+	case source.HTTP != nil:
+		return "http"
+	case source.OCI != nil:
+		return "oci"
+	case source.ConfigMap != nil:
+		return "configMap"
+	case source.S3 != nil:
+		return "s3"
+	}
+	return ""
+}
+
+// validateSource checks the sources of the website: a single one from
+// gitRepo or source, or the named ones of sources each mounted at its own
+// path.
+func validateSource(website *myv1alpha1.Website) error {
+	if len(website.Spec.Sources) == 0 {
+		if website.Spec.Source != nil && (website.Spec.GitRepo != "" || website.Spec.Branch != "") {
+			return fmt.Errorf("gitRepo and branch cannot be combined with source")
+		}
+		return validateSourceMembers("source", websiteSource(website))
+	}
+	if website.Spec.Source != nil || website.Spec.GitRepo != "" || website.Spec.Branch != "" {
+		return fmt.Errorf("gitRepo, branch and source cannot be combined with sources")
+	}
+	names := map[string]bool{}
+	mountPaths := map[string]bool{}
+	for i, source := range website.Spec.Sources {
+		field := fmt.Sprintf("sources[%d]", i)
+		if !sourceNamePattern.MatchString(source.Name) {
+			return fmt.Errorf("%s: name %q must be a lowercase DNS label of at most 40 characters", field, source.Name)
+		}
+		if names[source.Name] {
+			return fmt.Errorf("%s: duplicate name %q", field, source.Name)
+		}
+		names[source.Name] = true
+		mountPath := sourceMountPath(source)
+		if !locationPattern.MatchString(mountPath) || path.Clean(mountPath) != mountPath {
+			return fmt.Errorf("%s: mountPath %q must be a clean absolute path of letters, digits, '.', '_', '-' and '/'", field, mountPath)
+		}
+		if (i == 0) != (mountPath == "/") {
+			return fmt.Errorf("%s: the first source and only it is mounted at /", field)
+		}
+		if mountPaths[mountPath] {
+			return fmt.Errorf("%s: duplicate mountPath %q", field, mountPath)
+		}
+		mountPaths[mountPath] = true
+		if err := validateSourceMembers(field, source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSourceMembers checks that exactly one member of source is set and
+// that it can be fetched.
+func validateSourceMembers(field string, source myv1alpha1.WebsiteSource) error {
+	set := 0
+	for _, member := range []bool{source.Git != nil, source.HTTP != nil, source.OCI != nil, source.ConfigMap != nil, source.S3 != nil} {
+		if member {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("%s must set exactly one of git, http, oci, configMap and s3", field)
+	}
+	switch {
+	case source.Git != nil:
+		if err := validateGitSource(source.Git); err != nil {
+			return fmt.Errorf("%s: %v", field, err)
+		}
+	case source.HTTP != nil:
+		u, err := url.Parse(source.HTTP.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s.http.url %q must be an http or https URL", field, source.HTTP.URL)
+		}
+		if !archivePattern.MatchString(u.Path) {
+			return fmt.Errorf("%s.http.url %q must point to a .tar, .tar.gz, .tgz or .zip archive", field, source.HTTP.URL)
+		}
+		if !sha256Pattern.MatchString(source.HTTP.SHA256) {
+			return fmt.Errorf("%s.http.sha256 must be a hex encoded SHA-256 checksum", field)
+		}
+	case source.OCI != nil:
+		if source.OCI.Reference == "" || strings.ContainsAny(source.OCI.Reference, " \t") || hasControlChars(source.OCI.Reference) {
+			return fmt.Errorf("%s.oci.reference %q is not a valid reference", field, source.OCI.Reference)
+		}
+	case source.ConfigMap != nil:
+		if source.ConfigMap.Name == "" {
+			return fmt.Errorf("%s.configMap.name is required", field)
+		}
+	case source.S3 != nil:
+		u, err := url.Parse(source.S3.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s.s3.endpoint %q must be an http or https URL", field, source.S3.Endpoint)
+		}
+		if source.S3.Bucket == "" {
+			return fmt.Errorf("%s.s3.bucket is required", field)
+		}
+		if source.S3.Prefix != "" && !relativePathPattern.MatchString(source.S3.Prefix) {
+			return fmt.Errorf("%s.s3.prefix %q may only contain letters, digits, '.', '_', '-' and '/'", field, source.S3.Prefix)
+		}
+	}
+	return nil
+}
+
+// validateGitSource checks the repository and branch passed to git, which
+// must not be taken for options.
+func validateGitSource(git *myv1alpha1.GitSource) error {
+	if git.Repo == "" {
+		return fmt.Errorf("a git repository is required")
+	}
+	if strings.HasPrefix(git.Repo, "-") || hasControlChars(git.Repo) {
+		return fmt.Errorf("git repository %q must not start with '-' or contain control characters", git.Repo)
+	}
+	return validateBranch(git.Branch)
+}
+
+// validateBranch checks a git branch name, empty for the default branch.
+func validateBranch(branch string) error {
+	if strings.HasPrefix(branch, "-") || strings.ContainsAny(branch, " ~^:?*[\\") || strings.Contains(branch, "..") || hasControlChars(branch) {
+		return fmt.Errorf("branch %q is not a valid git branch name", branch)
+	}
+	return nil
+}
+
+// hashJSON returns a short hash of the JSON encoding of v, for pod template
+// annotations.
+func hashJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:10]
+}
+
+// extraSource is a source served next to the primary one, as hashed into
+// sourcesAnnotation.
+type extraSource struct {
+	Source   myv1alpha1.WebsiteSource `json:"source"`
+	Revision string                   `json:"revision,omitempty"`
+}
+
+// applySources fills the html volume of a website pod with the primary
+// source, unless a build does, and mounts every other source at its path.
+func applySources(template *corev1.PodTemplateSpec, website *myv1alpha1.Website, revision string) {
+	sources := websiteSources(website)
+	if sources[0].Git == nil {
+		template.Annotations[sourceAnnotation] = hashJSON(sources[0])
+	}
+	if website.Spec.Build == nil {
+		if len(sources) > 1 && sources[0].Git != nil {
+			// The mount points of the other sources are created in the html
+			// volume when the server starts, git-sync only clones into an
+			// empty directory
+			addFetchContainer(template, sources[0], "", revision, corev1.VolumeMount{Name: "html", MountPath: "/site"})
+		}
+		fillSource(template, sources[0], "html", "", revision)
+	}
+	if len(website.Spec.Sources) == 0 {
+		return
+	}
+
+	root := serverRoot(backendFor(website), website)
+	extras := make([]extraSource, 0, len(sources)-1)
+	for _, source := range sources[1:] {
+		volume := "source-" + source.Name
+		revision := pinnedRevision(website, source)
+		extras = append(extras, extraSource{Source: source, Revision: revision})
+		template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+			Name: volume,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+		fillSource(template, source, volume, "-"+source.Name, revision)
+		if container := servingContainer(template, website); container != nil {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      volume,
+				MountPath: path.Join(root, source.MountPath),
+				ReadOnly:  true,
+			})
+		}
+	}
+	template.Annotations[sourcesAnnotation] = hashJSON(extras)
+}
+
+// pinnedRevision returns the revision resolved for a git source served next
+// to the primary one, empty to follow the branch head.
+func pinnedRevision(website *myv1alpha1.Website, source myv1alpha1.WebsiteSource) string {
+	if source.Git == nil {
+		return ""
+	}
+	if st := findSourceStatus(website.Status.Sources, source.Name); st != nil && st.ResolvedRef == gitSourceRef(source.Git) {
+		return st.Revision
+	}
+	return ""
+}
+
+// fillSource fills volume with a source: git and S3 sources are kept in
+// sync by a sidecar, HTTP and OCI artifacts are fetched once by an init
+// container and a ConfigMap is mounted as is. suffix tells the containers
+// of several sources apart.
+func fillSource(template *corev1.PodTemplateSpec, source myv1alpha1.WebsiteSource, volume, suffix, revision string) {
+	dest := corev1.VolumeMount{Name: volume, MountPath: "/site"}
+	switch {
+	case source.Git != nil:
+		template.Spec.Containers = append(template.Spec.Containers, gitSyncContainer(source.Git, volume, suffix, revision))
+	case source.ConfigMap != nil:
+		for i := range template.Spec.Volumes {
+			if template.Spec.Volumes[i].Name == volume {
+				template.Spec.Volumes[i].VolumeSource = corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: source.ConfigMap.Name},
+					},
+				}
+			}
+		}
+	case source.S3 != nil:
+		addFetchContainer(template, source, suffix, revision, dest)
+		sync := s3Container("s3-sync"+suffix, source.S3, dest)
+		sync.Command = []string{"sh", "-c", fmt.Sprintf("while :; do %s; sleep %d; done", s3SyncScript, s3SyncInterval)}
+		sync.VolumeMounts = []corev1.VolumeMount{dest}
+		template.Spec.Containers = append(template.Spec.Containers, sync)
+	default:
+		addFetchContainer(template, source, suffix, revision, dest)
+	}
+}
+
+// fetchContainerName returns the name of the init container fetching a
+// source once.
+func fetchContainerName(source myv1alpha1.WebsiteSource) string {
+	switch {
+	case source.Git != nil:
+		return "git-clone"
+	case source.HTTP != nil:
+		return "download"
+	case source.OCI != nil:
+		return "oras-pull"
+	case source.ConfigMap != nil:
+		return "copy-configmap"
+	}
+	return "s3-fetch"
+}
+
+// syncContainerNames returns the names of the containers fetching a source
+// into the pods, with the suffix of the source. A ConfigMap mounted as is
+// has none.
+func syncContainerNames(source myv1alpha1.WebsiteSource, suffix string, build bool) []string {
+	switch {
+	case build:
+		return []string{fetchContainerName(source) + suffix}
+	case source.Git != nil:
+		return []string{"git-sync" + suffix}
+	case source.ConfigMap != nil:
+		return nil
+	case source.S3 != nil:
+		return []string{"s3-fetch" + suffix, "s3-sync" + suffix}
+	}
+	return []string{fetchContainerName(source) + suffix}
+}
+
+// addFetchContainer adds an init container fetching a source once into
+// dest.
+func addFetchContainer(template *corev1.PodTemplateSpec, source myv1alpha1.WebsiteSource, suffix, revision string, dest corev1.VolumeMount) {
+	destEnv := corev1.EnvVar{Name: "DEST", Value: dest.MountPath}
+	container := corev1.Container{Name: fetchContainerName(source) + suffix}
+	switch {
+	case source.Git != nil:
+		container.Image = "alpine/git"
+		container.Command = []string{"sh", "-c", cloneScript}
+		container.Env = []corev1.EnvVar{
+			{Name: "GIT_REPO", Value: source.Git.Repo},
+			{Name: "GIT_BRANCH", Value: gitBranch(source.Git)},
+			{Name: "GIT_REV", Value: revision},
+			destEnv,
+		}
+	case source.HTTP != nil:
+		container.Image = "alpine:3"
+		container.Command = []string{"sh", "-c", downloadScript}
+		container.Env = []corev1.EnvVar{
+			{Name: "SOURCE_URL", Value: source.HTTP.URL},
+			{Name: "SOURCE_SHA256", Value: source.HTTP.SHA256},
+			destEnv,
+		}
+	case source.OCI != nil:
+		command := []string{"oras", "pull", "--output", dest.MountPath}
+		if source.OCI.PlainHTTP {
+			command = append(command, "--plain-http")
+		}
+		container.Image = "ghcr.io/oras-project/oras:v1.2.0"
+		container.Command = append(command, source.OCI.Reference)
+	case source.ConfigMap != nil:
+		volume := "source-configmap" + suffix
+		template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+			Name: volume,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: source.ConfigMap.Name},
+				},
+			},
+		})
+		container.Image = "alpine:3"
+		container.Command = []string{"sh", "-c", copyConfigMapScript}
+		container.Env = []corev1.EnvVar{destEnv}
+		container.VolumeMounts = []corev1.VolumeMount{
+			{Name: volume, MountPath: sourceConfigMapDir, ReadOnly: true},
+		}
+	case source.S3 != nil:
+		container = s3Container(container.Name, source.S3, dest)
+		container.Command = []string{"sh", "-c", s3SyncScript}
+	}
+	container.VolumeMounts = append(container.VolumeMounts, dest)
+	template.Spec.InitContainers = append(template.Spec.InitContainers, container)
+}
+
+// gitSyncContainer returns the sidecar keeping volume at revision.
+func gitSyncContainer(git *myv1alpha1.GitSource, volume, suffix, revision string) corev1.Container {
+	gitRev := revision
+	if gitRev == "" {
+		gitRev = "FETCH_HEAD"
+	}
+	return corev1.Container{
+		// git sync container for fetching code
+		Name:  "git-sync" + suffix,
+		Image: "openweb/git-sync",
+		Env: []corev1.EnvVar{
+			{
+				Name:  "GIT_SYNC_REPO",
+				Value: git.Repo,
+			},
+			{
+				Name:  "GIT_SYNC_DEST",
+				Value: "/gitrepo",
+			},
+			{
+				Name:  "GIT_SYNC_BRANCH",
+				Value: gitBranch(git),
+			},
+			{
+				Name:  "GIT_SYNC_REV",
+				Value: gitRev,
+			},
+			{
+				Name:  "GIT_SYNC_WAIT",
+				Value: "3600",
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      volume,
+				MountPath: "/gitrepo",
+			},
+		},
+	}
+}
+
+// s3Container returns a container with the aws cli set up to sync an S3
+// source into dest, without command and mounts.
+func s3Container(name string, s3 *myv1alpha1.S3Source, dest corev1.VolumeMount) corev1.Container {
+	env := []corev1.EnvVar{
+		{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+		{Name: "S3_BUCKET", Value: s3.Bucket},
+		{Name: "S3_PREFIX", Value: s3.Prefix},
+		{Name: "AWS_DEFAULT_REGION", Value: "us-east-1"},
+		{Name: "DEST", Value: dest.MountPath},
+	}
+	if s3.SecretName != "" {
+		for _, key := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"} {
+			env = append(env, corev1.EnvVar{
+				Name: key,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: s3.SecretName},
+						Key:                  key,
+					},
+				},
+			})
+		}
+	}
+	return corev1.Container{
+		Name:  name,
+		Image: "amazon/aws-cli",
+		Env:   env,
+	}
+}
+
+// gitSourceRef returns the repository and branch of a git source, in the
+// form recorded in status.sources.
+func gitSourceRef(git *myv1alpha1.GitSource) string {
+	return git.Repo + "#" + gitBranch(git)
+}
+
+func findSourceStatus(statuses []myv1alpha1.SourceStatus, name string) *myv1alpha1.SourceStatus {
+	for i := range statuses {
+		if statuses[i].Name == name {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// syncSources reports each of spec.sources in status.sources. The primary
+// source is at revision, the branches of the other git sources are resolved
+// when due so the pods can be pinned to them.
+func (c *Controller) syncSources(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, revision string) error {
+	if len(website.Spec.Sources) == 0 {
+		status.Sources = nil
+		return nil
+	}
+	pods, err := c.websitePods(website)
+	if err != nil {
+		return err
+	}
+
+	statuses := make([]myv1alpha1.SourceStatus, 0, len(website.Spec.Sources))
+	for i, source := range website.Spec.Sources {
+		st := myv1alpha1.SourceStatus{
+			Name:      source.Name,
+			MountPath: sourceMountPath(source),
+			Type:      sourceType(source),
+		}
+		old := findSourceStatus(status.Sources, source.Name)
+		suffix := ""
+		if i > 0 {
+			suffix = "-" + source.Name
+		}
+		switch {
+		case source.Git != nil && i == 0:
+			st.Revision = revision
+			st.ResolvedRef = gitSourceRef(source.Git)
+		case source.Git != nil:
+			c.resolveSource(website, source.Git, old, &st)
+		case source.HTTP != nil:
+			st.Revision = "sha256:" + source.HTTP.SHA256
+		case source.OCI != nil:
+			st.Revision = source.OCI.Reference
+		case source.ConfigMap != nil:
+			configMap, err := c.configMapsLister.ConfigMaps(website.Namespace).Get(source.ConfigMap.Name)
+			if errors.IsNotFound(err) {
+				st.Phase = myv1alpha1.SourceFailed
+				st.Message = fmt.Sprintf("ConfigMap %q not found", source.ConfigMap.Name)
+			} else if err != nil {
+				return err
+			} else {
+				st.Revision = configMap.ResourceVersion
+			}
+		}
+		if st.Phase == "" {
+			st.Phase, st.Message = sourcePhase(pods, syncContainerNames(source, suffix, i == 0 && website.Spec.Build != nil))
+		}
+		if st.Phase == myv1alpha1.SourceFailed && (old == nil || old.Phase != st.Phase || old.Message != st.Message) {
+			c.recorder.Eventf(website, corev1.EventTypeWarning, ErrSyncSource, MessageSyncSourceFailed, source.Name, st.Message)
+		}
+		statuses = append(statuses, st)
+	}
+	status.Sources = statuses
+	return nil
+}
+
+// resolveSource resolves the branch of a git source served next to the
+// primary one when it was not resolved yet, it changed or the poll interval
+// elapsed. The resolution runs in the background, the website is synced
+// again once it returned.
+func (c *Controller) resolveSource(website *myv1alpha1.Website, git *myv1alpha1.GitSource, old, st *myv1alpha1.SourceStatus) {
+	ref := gitSourceRef(git)
+	st.ResolvedRef = ref
+	if old != nil && old.ResolvedRef == ref {
+		st.Revision = old.Revision
+		st.LastResolveTime = old.LastResolveTime
+	}
+
+	var revision string
+	var err error
+	resolved := false
+	if st.LastResolveTime == nil || time.Since(st.LastResolveTime.Time) >= c.gitPollInterval {
+		revision, resolved, err = c.resolve(git.Repo, gitBranch(git), func() { c.enqueueWebsiteAfter(website, 0) })
+	}
+	if !resolved {
+		// the last resolution stands until the next one returned
+		if st.LastResolveTime != nil && st.Revision == "" {
+			// the last resolution failed
+			st.Phase, st.Message = old.Phase, old.Message
+		}
+		return
+	}
+	now := metav1.Now()
+	st.LastResolveTime = &now
+	if err != nil {
+		// The pods stay pinned to the previous revision, if any
+		st.Phase = myv1alpha1.SourceFailed
+		st.Message = err.Error()
+		return
+	}
+	st.Revision = revision
+}
+
+// sourcePhase tells from the containers named in the website pods whether
+// a pod fetched the source: its init containers completed and its sidecars
+// run.
+func sourcePhase(pods []*corev1.Pod, names []string) (myv1alpha1.SourcePhase, string) {
+	if len(names) == 0 {
+		return myv1alpha1.SourceSynced, ""
+	}
+	phase, message := myv1alpha1.SourceSyncing, ""
+	for _, pod := range pods {
+		if pod.Labels["app"] == "website-nginx-preview" {
+			continue
+		}
+		synced := true
+		for _, name := range names {
+			st, init := containerStatus(pod, name)
+			switch {
+			case st == nil:
+				synced = false
+			case st.State.Terminated != nil && st.State.Terminated.ExitCode == 0:
+			case st.State.Running != nil && !init:
+			case st.State.Terminated != nil:
+				synced = false
+				phase = myv1alpha1.SourceFailed
+				message = fmt.Sprintf("container %s of pod %s exited with code %d", name, pod.Name, st.State.Terminated.ExitCode)
+			case st.LastTerminationState.Terminated != nil && st.LastTerminationState.Terminated.ExitCode != 0:
+				synced = false
+				phase = myv1alpha1.SourceFailed
+				message = fmt.Sprintf("container %s of pod %s exited with code %d", name, pod.Name, st.LastTerminationState.Terminated.ExitCode)
+			default:
+				synced = false
+			}
+		}
+		if synced {
+			return myv1alpha1.SourceSynced, ""
+		}
+	}
+	return phase, message
+}
+
+// containerStatus returns the status of the named container of a pod, and
+// whether it is an init container.
+func containerStatus(pod *corev1.Pod, name string) (*corev1.ContainerStatus, bool) {
+	for i := range pod.Status.InitContainerStatuses {
+		if pod.Status.InitContainerStatuses[i].Name == name {
+			return &pod.Status.InitContainerStatuses[i], true
+		}
+	}
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == name {
+			return &pod.Status.ContainerStatuses[i], false
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const indexHTML = "<h1>kubia</h1>\n"
+
+func TestSyncDeploymentRollsTemplateAnnotations(t *testing.T) {
+	website := &myv1alpha1.Website{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia", UID: types.UID("kubia-uid")}}
+	for _, annotation := range []string{
+		revisionAnnotation,
+		buildAnnotation,
+		configHashAnnotation,
+		resourcesAnnotation,
+		overridesHashAnnotation,
+		sourceAnnotation,
+		sourcesAnnotation,
+		contentPathAnnotation,
+	} {
+		t.Run(annotation, func(t *testing.T) {
+			replicas := int32(1)
+			current := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "default",
+					Name:            "kubia",
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(website, myv1alpha1.SchemeGroupVersion.WithKind("Website"))},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &replicas,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{annotation: "old"}},
+					},
+				},
+			}
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(current); err != nil {
+				t.Fatal(err)
+			}
+			client := fake.NewSimpleClientset(current)
+			c := &Controller{
+				kubeclientset:     client,
+				deploymentsLister: appslisters.NewDeploymentLister(indexer),
+				recorder:          record.NewFakeRecorder(10),
+			}
+
+			desired := current.DeepCopy()
+			desired.Spec.Template.Annotations[annotation] = "new"
+			deployment, err := c.syncDeployment(website, desired)
+			if err != nil {
+				t.Fatalf("syncDeployment() error = %v", err)
+			}
+			if got := deployment.Spec.Template.Annotations[annotation]; got != "new" {
+				t.Errorf("%s = %q after sync, want new", annotation, got)
+			}
+			updated := false
+			for _, action := range client.Actions() {
+				updated = updated || action.GetVerb() == "update"
+			}
+			if !updated {
+				t.Errorf("the Deployment was not updated, actions: %v", client.Actions())
+			}
+		})
+	}
+}
+
+// runFetchContainer runs the fetch init container of source the way the pod
+// would, with dest as its destination volume. The test is skipped unless
+// the tools the container needs are installed.
+func runFetchContainer(t *testing.T, source myv1alpha1.WebsiteSource, revision, dest string, tools ...string) (string, error) {
+	t.Helper()
+	template := &corev1.PodTemplateSpec{}
+	addFetchContainer(template, source, "", revision, corev1.VolumeMount{Name: "html", MountPath: dest})
+	container := template.Spec.InitContainers[0]
+	for _, tool := range append([]string{container.Command[0]}, tools...) {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not installed", tool)
+		}
+	}
+	cmd := exec.Command(container.Command[0], container.Command[1:]...)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dest + "/..", "GIT_TERMINAL_PROMPT=0"}
+	for _, env := range container.Env {
+		if env.ValueFrom == nil {
+			cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+		}
+	}
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// newDest returns an empty destination directory below dir.
+func newDest(t *testing.T, dir string) string {
+	t.Helper()
+	dest := filepath.Join(dir, "html")
+	if err := os.Mkdir(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dest
+}
+
+// checkIndex fails unless dest holds the index.html of the test site.
+func checkIndex(t *testing.T, dest string) {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join(dest, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != indexHTML {
+		t.Errorf("index.html = %q, want %q", data, indexHTML)
+	}
+}
+
+func TestFetchGitSource(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	bare, sha := newBareRepo(t, dir)
+	dest := filepath.Join(dir, "html")
+
+	source := myv1alpha1.WebsiteSource{Git: &myv1alpha1.GitSource{Repo: "file://" + bare}}
+	if out, err := runFetchContainer(t, source, sha, dest, "git"); err != nil {
+		t.Fatalf("fetch failed: %v: %s", err, out)
+	}
+	checkIndex(t, dest)
+}
+
+// archives returns the test site packed as the archives HTTP sources
+// extract, by file name.
+func archives(t *testing.T) map[string][]byte {
+	t.Helper()
+	files := map[string]string{"index.html": indexHTML}
+
+	var tarball bytes.Buffer
+	tw := tar.NewWriter(&tarball)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(tarball.Bytes())
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string][]byte{
+		"site.tar":    tarball.Bytes(),
+		"site.tar.gz": gzipped.Bytes(),
+		"site.tgz":    gzipped.Bytes(),
+		"site.zip":    zipped.Bytes(),
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetchHTTPSource(t *testing.T) {
+	files := archives(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, ok := files[strings.TrimPrefix(req.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		sha256  string
+		wantErr bool
+	}{
+		{name: "tar", url: server.URL + "/site.tar", sha256: sha256Hex(files["site.tar"])},
+		{name: "tar.gz", url: server.URL + "/site.tar.gz", sha256: sha256Hex(files["site.tar.gz"])},
+		{name: "tgz with query", url: server.URL + "/site.tgz?token=s3cret", sha256: sha256Hex(files["site.tgz"])},
+		{name: "zip", url: server.URL + "/site.zip", sha256: sha256Hex(files["site.zip"])},
+		{name: "checksum mismatch", url: server.URL + "/site.tar.gz", sha256: sha256Hex(files["site.zip"]), wantErr: true},
+		{name: "not found", url: server.URL + "/missing.tar", sha256: sha256Hex(nil), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+			dest := newDest(t, dir)
+
+			source := myv1alpha1.WebsiteSource{HTTP: &myv1alpha1.HTTPSource{URL: tt.url, SHA256: tt.sha256}}
+			out, err := runFetchContainer(t, source, "", dest, "wget", "sha256sum", "tar", "unzip")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("fetch succeeded")
+				}
+				if entries, _ := ioutil.ReadDir(dest); len(entries) > 0 {
+					t.Errorf("a failed fetch extracted %d files", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch failed: %v: %s", err, out)
+			}
+			checkIndex(t, dest)
+		})
+	}
+}
+
+// newRegistry returns a stand-in for a local OCI registry serving the test
+// site as the artifact site:v1, index.html being its only layer.
+func newRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+	config := []byte("{}")
+	layer := []byte(indexHTML)
+	blobs := map[string][]byte{
+		"sha256:" + sha256Hex(config): config,
+		"sha256:" + sha256Hex(layer):  layer,
+	}
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config": map[string]interface{}{
+			"mediaType": "application/vnd.oci.empty.v1+json",
+			"digest":    "sha256:" + sha256Hex(config),
+			"size":      len(config),
+		},
+		"layers": []map[string]interface{}{{
+			"mediaType":   "text/html",
+			"digest":      "sha256:" + sha256Hex(layer),
+			"size":        len(layer),
+			"annotations": map[string]string{"org.opencontainers.image.title": "index.html"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDigest := "sha256:" + sha256Hex(manifest)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var data []byte
+		var contentType, digest string
+		switch p := req.URL.Path; {
+		case p == "/v2/":
+			return
+		case p == "/v2/site/manifests/v1" || p == "/v2/site/manifests/"+manifestDigest:
+			data, contentType, digest = manifest, "application/vnd.oci.image.manifest.v1+json", manifestDigest
+		case strings.HasPrefix(p, "/v2/site/blobs/"):
+			digest = strings.TrimPrefix(p, "/v2/site/blobs/")
+			var ok bool
+			if data, ok = blobs[digest]; !ok {
+				http.NotFound(w, req)
+				return
+			}
+			contentType = "application/octet-stream"
+		default:
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		if req.Method != http.MethodHead {
+			w.Write(data)
+		}
+	}))
+}
+
+func TestFetchOCISource(t *testing.T) {
+	registry := newRegistry(t)
+	defer registry.Close()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	dest := newDest(t, dir)
+
+	reference := strings.TrimPrefix(registry.URL, "http://") + "/site:v1"
+	source := myv1alpha1.WebsiteSource{OCI: &myv1alpha1.OCISource{Reference: reference, PlainHTTP: true}}
+	if out, err := runFetchContainer(t, source, "", dest); err != nil {
+		t.Fatalf("fetch failed: %v: %s", err, out)
+	}
+	checkIndex(t, dest)
+}
+
+// newObjectStore returns a stand-in for a MinIO server holding the test
+// site below site/ in the bucket web, read anonymously with path-style
+// requests.
+func newObjectStore(t *testing.T) *httptest.Server {
+	t.Helper()
+	objects := map[string]string{"site/index.html": indexHTML}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key := strings.TrimPrefix(req.URL.Path, "/web")
+		switch {
+		case key == "" || key == "/":
+			// ListObjectsV2
+			prefix := req.URL.Query().Get("prefix")
+			var b strings.Builder
+			b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>web</Name>`)
+			fmt.Fprintf(&b, "<Prefix>%s</Prefix><IsTruncated>false</IsTruncated>", prefix)
+			for name, content := range objects {
+				if strings.HasPrefix(name, prefix) {
+					fmt.Fprintf(&b, `<Contents><Key>%s</Key><LastModified>2019-11-01T00:00:00.000Z</LastModified><ETag>"%s"</ETag><Size>%d</Size><StorageClass>STANDARD</StorageClass></Contents>`,
+						name, sha256Hex([]byte(content))[:32], len(content))
+				}
+			}
+			b.WriteString("</ListBucketResult>")
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(b.String()))
+		default:
+			content, ok := objects[strings.TrimPrefix(key, "/")]
+			if !ok {
+				http.NotFound(w, req)
+				return
+			}
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			w.Header().Set("Last-Modified", "Fri, 01 Nov 2019 00:00:00 GMT")
+			if req.Method != http.MethodHead {
+				w.Write([]byte(content))
+			}
+		}
+	}))
+}
+
+func TestFetchS3Source(t *testing.T) {
+	store := newObjectStore(t)
+	defer store.Close()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	dest := newDest(t, dir)
+
+	source := myv1alpha1.WebsiteSource{S3: &myv1alpha1.S3Source{Endpoint: store.URL, Bucket: "web", Prefix: "site"}}
+	if out, err := runFetchContainer(t, source, "", dest, "aws"); err != nil {
+		t.Fatalf("fetch failed: %v: %s", err, out)
+	}
+	checkIndex(t, dest)
+}
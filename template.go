@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+	clientset "github.com/nevermosby/my-crd-controller/pkg/client/clientset/versioned"
+)
+
+// templateIndex indexes websites by the WebsiteTemplate they inherit from.
+const templateIndex = "template"
+
+// uninheritedFields are the spec fields a WebsiteTemplate cannot set.
+// Adopting existing objects is opted into by each Website.
+var uninheritedFields = []string{"deploymentName", "template", "adoptExisting"}
+
+// indexWebsiteByTemplate is the templateIndex function of the websites.
+func indexWebsiteByTemplate(obj interface{}) ([]string, error) {
+	website, ok := websiteOf(obj)
+	if !ok || website.Spec.Template == "" {
+		return nil, nil
+	}
+	return []string{website.Spec.Template}, nil
+}
+
+// validateWebsiteTemplate returns an error when the template enforces a
+// field the Website spec does not have or that cannot be inherited.
+func validateWebsiteTemplate(template *myv1alpha1.WebsiteTemplate) error {
+	fields := websiteSpecFields()
+	for i, field := range template.Spec.Enforced {
+		if !fields[field] {
+			return fmt.Errorf("spec.enforced[%d]: %q is not an inherited field of the Website spec", i, field)
+		}
+	}
+	return nil
+}
+
+// websiteSpecFields returns the JSON names of the inherited fields of the
+// Website spec.
+func websiteSpecFields() map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(myv1alpha1.WebsiteSpec{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	for _, field := range uninheritedFields {
+		delete(fields, field)
+	}
+	return fields
+}
+
+// mergeWebsiteSpec returns the effective spec of a Website inheriting from
+// template. A field set in spec wins over the default of the template,
+// objects are merged field by field, lists and scalars replaced. Fields the
+// template enforces are taken from it as they are.
+func mergeWebsiteSpec(template *myv1alpha1.WebsiteTemplate, spec myv1alpha1.WebsiteSpec) (myv1alpha1.WebsiteSpec, error) {
+	if err := validateWebsiteTemplate(template); err != nil {
+		return spec, err
+	}
+	defaults, err := specFields(template.Spec.Defaults)
+	if err != nil {
+		return spec, err
+	}
+	for _, field := range uninheritedFields {
+		delete(defaults, field)
+	}
+	own, err := specFields(spec)
+	if err != nil {
+		return spec, err
+	}
+
+	merged := mergeFields(deepCopyFields(defaults), own)
+	for _, field := range template.Spec.Enforced {
+		if value, ok := defaults[field]; ok && isSet(value) {
+			merged[field] = value
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return spec, err
+	}
+	effective := myv1alpha1.WebsiteSpec{}
+	if err := json.Unmarshal(data, &effective); err != nil {
+		return spec, err
+	}
+	return effective, nil
+}
+
+// specFields returns the fields of spec as decoded JSON.
+func specFields(spec myv1alpha1.WebsiteSpec) (map[string]interface{}, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// mergeFields merges the set fields of override into base, recursing into
+// objects set in both.
+func mergeFields(base, override map[string]interface{}) map[string]interface{} {
+	for key, value := range override {
+		if !isSet(value) {
+			continue
+		}
+		if overrideObject, ok := value.(map[string]interface{}); ok {
+			if baseObject, ok := base[key].(map[string]interface{}); ok {
+				base[key] = mergeFields(baseObject, overrideObject)
+				continue
+			}
+		}
+		base[key] = value
+	}
+	return base
+}
+
+// deepCopyFields copies decoded JSON objects, so merging does not modify
+// the defaults.
+func deepCopyFields(fields map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if object, ok := value.(map[string]interface{}); ok {
+			value = deepCopyFields(object)
+		}
+		copied[key] = value
+	}
+	return copied
+}
+
+// isSet returns whether a decoded JSON field is set, the spec writes unset
+// fields without omitempty as null or "".
+func isSet(value interface{}) bool {
+	return value != nil && value != ""
+}
+
+// resolveWebsite returns a copy of website with the effective spec, merged
+// with its WebsiteTemplate, and the template. Websites without one are
+// returned as they are.
+func (c *Controller) resolveWebsite(website *myv1alpha1.Website) (*myv1alpha1.Website, *myv1alpha1.WebsiteTemplate, error) {
+	if website.Spec.Template == "" {
+		return website, nil, nil
+	}
+	template, err := c.templatesLister.Get(website.Spec.Template)
+	if err != nil {
+		return nil, nil, err
+	}
+	spec, err := mergeWebsiteSpec(template, website.Spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("WebsiteTemplate %s: %v", template.Name, err)
+	}
+	effective := website.DeepCopy()
+	effective.Spec = spec
+	return effective, template, nil
+}
+
+// resolveWebsites resolves the effective spec of websites, keeping the ones
+// whose template cannot be resolved as they are.
+func (c *Controller) resolveWebsites(websites []*myv1alpha1.Website) []*myv1alpha1.Website {
+	resolved := make([]*myv1alpha1.Website, 0, len(websites))
+	for _, website := range websites {
+		if effective, _, err := c.resolveWebsite(website); err == nil {
+			website = effective
+		}
+		resolved = append(resolved, website)
+	}
+	return resolved
+}
+
+// syncTemplateCondition sets the TemplateResolved condition of a website
+// inheriting from template, or the error resolving it.
+func syncTemplateCondition(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus, template *myv1alpha1.WebsiteTemplate, err error) {
+	switch {
+	case website.Spec.Template == "":
+		removeWebsiteCondition(status, myv1alpha1.WebsiteTemplateResolved)
+	case err != nil:
+		setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteTemplateResolved, corev1.ConditionFalse, ErrResolveTemplate, err.Error()))
+	default:
+		msg := fmt.Sprintf(MessageTemplateResolved, template.Name, template.Generation)
+		setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteTemplateResolved, corev1.ConditionTrue, TemplateResolved, msg))
+	}
+}
+
+// enqueueTemplateWebsites enqueues the websites and ClusterWebsites
+// inheriting from a WebsiteTemplate, found through templateIndex.
+func (c *Controller) enqueueTemplateWebsites(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	template, ok := obj.(*myv1alpha1.WebsiteTemplate)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type %T", obj))
+		return
+	}
+	objs, err := c.websitesIndexer.ByIndex(templateIndex, template.Name)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	clusterObjs, err := c.clusterWebsitesIndexer.ByIndex(templateIndex, template.Name)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, obj := range append(objs, clusterObjs...) {
+		c.enqueueWebsite(obj)
+	}
+}
+
+// printEffectiveSpec writes the effective spec of the Website named by key,
+// namespace/name, or of the ClusterWebsite named by a bare name, as YAML.
+func printEffectiveSpec(client clientset.Interface, key string, out io.Writer) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	var spec myv1alpha1.WebsiteSpec
+	if namespace == "" {
+		clusterWebsite, err := client.MycontrollerV1alpha1().ClusterWebsites().Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		spec = clusterWebsite.Spec.WebsiteSpec
+	} else {
+		website, err := client.MycontrollerV1alpha1().Websites(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		spec = website.Spec
+	}
+	if spec.Template != "" {
+		template, err := client.MycontrollerV1alpha1().WebsiteTemplates().Get(spec.Template, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if spec, err = mergeWebsiteSpec(template, spec); err != nil {
+			return fmt.Errorf("WebsiteTemplate %s: %v", template.Name, err)
+		}
+	}
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+func TestMergeWebsiteSpec(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	toleration := func(key string) corev1.Toleration {
+		return corev1.Toleration{Key: key, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	}
+
+	tests := []struct {
+		name     string
+		defaults myv1alpha1.WebsiteSpec
+		enforced []string
+		spec     myv1alpha1.WebsiteSpec
+		want     myv1alpha1.WebsiteSpec
+		wantErr  string
+	}{
+		{
+			name:     "unset fields are inherited",
+			defaults: myv1alpha1.WebsiteSpec{Branch: "gh-pages", ContentPath: "public", Replicas: int32Ptr(2)},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia"},
+			want:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Branch: "gh-pages", ContentPath: "public", Replicas: int32Ptr(2)},
+		},
+		{
+			name:     "set fields win",
+			defaults: myv1alpha1.WebsiteSpec{Branch: "gh-pages", Replicas: int32Ptr(2), ServiceType: corev1.ServiceTypeClusterIP},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Branch: "master", Replicas: int32Ptr(3)},
+			want:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Branch: "master", Replicas: int32Ptr(3), ServiceType: corev1.ServiceTypeClusterIP},
+		},
+		{
+			name:     "objects are merged field by field",
+			defaults: myv1alpha1.WebsiteSpec{Server: &myv1alpha1.WebsiteServer{Gzip: true, NotFoundPage: "/404.html"}},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Server: &myv1alpha1.WebsiteServer{SPAFallback: true, NotFoundPage: "/missing.html"}},
+			want:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Server: &myv1alpha1.WebsiteServer{Gzip: true, SPAFallback: true, NotFoundPage: "/missing.html"}},
+		},
+		{
+			name:     "maps are merged key by key",
+			defaults: myv1alpha1.WebsiteSpec{NodeSelector: map[string]string{"pool": "web", "zone": "a"}},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", NodeSelector: map[string]string{"zone": "b"}},
+			want:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", NodeSelector: map[string]string{"pool": "web", "zone": "b"}},
+		},
+		{
+			name:     "lists are replaced",
+			defaults: myv1alpha1.WebsiteSpec{Tolerations: []corev1.Toleration{toleration("web"), toleration("spot")}},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Tolerations: []corev1.Toleration{toleration("gpu")}},
+			want:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Tolerations: []corev1.Toleration{toleration("gpu")}},
+		},
+		{
+			name:     "enforced fields are taken as they are",
+			defaults: myv1alpha1.WebsiteSpec{PodSecurity: myv1alpha1.PodSecurityHardened, Server: &myv1alpha1.WebsiteServer{Gzip: true}},
+			enforced: []string{"podSecurity", "server"},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", PodSecurity: myv1alpha1.PodSecurityUnrestricted, Server: &myv1alpha1.WebsiteServer{NotFoundPage: "/404.html"}},
+			want:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", PodSecurity: myv1alpha1.PodSecurityHardened, Server: &myv1alpha1.WebsiteServer{Gzip: true}},
+		},
+		{
+			name:     "enforced fields without a default are left to the website",
+			enforced: []string{"ingress"},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Ingress: &myv1alpha1.WebsiteIngress{Host: "kubia.example.com"}},
+			want:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Ingress: &myv1alpha1.WebsiteIngress{Host: "kubia.example.com"}},
+		},
+		{
+			name:     "uninherited fields",
+			defaults: myv1alpha1.WebsiteSpec{DeploymentName: "shared", Template: "other", AdoptExisting: true},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Template: "base"},
+			want:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Template: "base"},
+		},
+		{
+			name:     "enforcing an uninherited field",
+			enforced: []string{"adoptExisting"},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia"},
+			wantErr:  `spec.enforced[0]: "adoptExisting" is not an inherited field`,
+		},
+		{
+			name:     "enforcing an unknown field",
+			enforced: []string{"podSecurity", "replica"},
+			spec:     myv1alpha1.WebsiteSpec{DeploymentName: "kubia"},
+			wantErr:  `spec.enforced[1]: "replica" is not an inherited field`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &myv1alpha1.WebsiteTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "base"},
+				Spec:       myv1alpha1.WebsiteTemplateSpec{Defaults: tt.defaults, Enforced: tt.enforced},
+			}
+			original := template.DeepCopy()
+			got, err := mergeWebsiteSpec(template, tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("mergeWebsiteSpec() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergeWebsiteSpec() error = %v", err)
+			}
+			if !equality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("mergeWebsiteSpec() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(template, original) {
+				t.Errorf("mergeWebsiteSpec() modified the template: %+v", template.Spec.Defaults)
+			}
+		})
+	}
+}
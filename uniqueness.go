@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// deploymentNameIndex, serviceNameIndex and hostIndex are the names of
+	// the Website and ClusterWebsite informer indexes keyed by the
+	// namespace/name of the Deployment and Service and by the ingress host,
+	// which no two websites may share.
+	deploymentNameIndex = "deploymentName"
+	serviceNameIndex    = "serviceName"
+	hostIndex           = "host"
+	// websiteNameIndex is keyed by the namespace/name of the websites. The
+	// name is the controller label selecting their pods, which a
+	// ClusterWebsite and a Website of its target namespace must not share.
+	websiteNameIndex = "websiteName"
+)
+
+// uniqueKey is a name a website holds in one of the uniqueness indexes.
+type uniqueKey struct {
+	index string
+	key   string
+	// what and name describe the key in messages
+	what string
+	name string
+}
+
+// websiteUniqueKeys returns the names website holds: its own name, its
+// Deployment and Service in its namespace and its host across the cluster.
+func websiteUniqueKeys(website *myv1alpha1.Website) []uniqueKey {
+	var keys []uniqueKey
+	if website.Namespace != "" {
+		keys = append(keys, uniqueKey{websiteNameIndex, website.Namespace + "/" + website.Name, "name", website.Name})
+	}
+	if website.Namespace != "" && website.Spec.DeploymentName != "" {
+		serviceName := websiteServiceName(website)
+		keys = append(keys,
+			uniqueKey{deploymentNameIndex, website.Namespace + "/" + website.Spec.DeploymentName, "deployment", website.Spec.DeploymentName},
+			uniqueKey{serviceNameIndex, website.Namespace + "/" + serviceName, "service", serviceName})
+	}
+	if website.Spec.Ingress != nil && website.Spec.Ingress.Host != "" {
+		host := strings.ToLower(website.Spec.Ingress.Host)
+		keys = append(keys, uniqueKey{hostIndex, host, "host", host})
+	}
+	return keys
+}
+
+// uniqueIndexFunc returns the cache.IndexFunc of a uniqueness index. The
+// websites are indexed by their stored spec, without their template.
+func uniqueIndexFunc(index string) cache.IndexFunc {
+	return func(obj interface{}) ([]string, error) {
+		website, ok := websiteOf(obj)
+		if !ok {
+			return nil, nil
+		}
+		var keys []string
+		for _, key := range websiteUniqueKeys(website) {
+			if key.index == index {
+				keys = append(keys, key.key)
+			}
+		}
+		return keys, nil
+	}
+}
+
+// websiteConflicts returns the names website shares with the other
+// Websites and ClusterWebsites for which conflicts returns true.
+func (c *Controller) websiteConflicts(website *myv1alpha1.Website, conflicts func(other *myv1alpha1.Website, key uniqueKey) bool) ([]string, error) {
+	own, err := websiteKey(website)
+	if err != nil {
+		return nil, err
+	}
+	return c.nameConflicts(websiteUniqueKeys(website), func(other *myv1alpha1.Website, key uniqueKey) bool {
+		otherKey, err := websiteKey(other)
+		return err == nil && otherKey != own && conflicts(other, key)
+	})
+}
+
+// nameConflicts returns which of keys are held by the Websites and
+// ClusterWebsites for which conflicts returns true.
+func (c *Controller) nameConflicts(keys []uniqueKey, conflicts func(other *myv1alpha1.Website, key uniqueKey) bool) ([]string, error) {
+	var messages []string
+	for _, key := range keys {
+		for _, indexer := range []cache.Indexer{c.websitesIndexer, c.clusterWebsitesIndexer} {
+			objs, err := indexer.ByIndex(key.index, key.key)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range objs {
+				other, _ := websiteOf(obj)
+				if conflicts(other, key) {
+					messages = append(messages, fmt.Sprintf("%s %q is used by %s", key.what, key.name, websiteDescription(other)))
+				}
+			}
+		}
+	}
+	return messages, nil
+}
+
+// websiteDescription names website in messages, with its kind.
+func websiteDescription(website *myv1alpha1.Website) string {
+	if isClusterWebsite(website) {
+		return "ClusterWebsite " + website.Name
+	}
+	return "Website " + website.Namespace + "/" + website.Name
+}
+
+// syncConflicts sets the Conflict condition of a website sharing a name with
+// an older one and returns the conflicts. The older website keeps the name,
+// the later one is not synced until it picks another or the older one
+// releases it.
+func (c *Controller) syncConflicts(website *myv1alpha1.Website, status *myv1alpha1.WebsiteStatus) ([]string, error) {
+	conflicts, err := c.websiteConflicts(website, func(other *myv1alpha1.Website, _ uniqueKey) bool {
+		return createdBefore(other, website)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) == 0 {
+		removeWebsiteCondition(status, myv1alpha1.WebsiteConflict)
+		return nil, nil
+	}
+	msg := strings.Join(conflicts, "; ")
+	if cond := getWebsiteCondition(*status, myv1alpha1.WebsiteConflict); cond == nil || cond.Status != corev1.ConditionTrue || cond.Message != msg {
+		c.recorder.Event(website, corev1.EventTypeWarning, ErrConflict, msg)
+	}
+	setWebsiteCondition(status, newWebsiteCondition(myv1alpha1.WebsiteConflict, corev1.ConditionTrue, ErrConflict, msg))
+	return conflicts, nil
+}
+
+// admitUnique returns an error when website takes a name held by another
+// Website or ClusterWebsite, old being the website it updates. Names old
+// already shared are left to the Conflict condition, so the update goes
+// through.
+func (c *Controller) admitUnique(website, old *myv1alpha1.Website) error {
+	held := map[uniqueKey]bool{}
+	if old != nil {
+		for _, key := range websiteUniqueKeys(old) {
+			held[key] = true
+		}
+	}
+	conflicts, err := c.websiteConflicts(website, func(_ *myv1alpha1.Website, key uniqueKey) bool {
+		return !held[key]
+	})
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// enqueueConflictingWebsites enqueues the other websites holding a name of
+// obj, a Website or ClusterWebsite that was deleted or renamed it, so one
+// in conflict with it is synced again.
+func (c *Controller) enqueueConflictingWebsites(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	website, ok := websiteOf(obj)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type %T", obj))
+		return
+	}
+	for _, key := range websiteUniqueKeys(website) {
+		for _, indexer := range []cache.Indexer{c.websitesIndexer, c.clusterWebsitesIndexer} {
+			objs, err := indexer.ByIndex(key.index, key.key)
+			if err != nil {
+				utilruntime.HandleError(err)
+				return
+			}
+			for _, obj := range objs {
+				c.enqueueWebsite(obj)
+			}
+		}
+	}
+}
+
+// uniqueKeysChanged returns whether an update of a Website or ClusterWebsite
+// changed the names it holds.
+func uniqueKeysChanged(old, new interface{}) bool {
+	oldWebsite, ok := websiteOf(old)
+	if !ok {
+		return false
+	}
+	newWebsite, ok := websiteOf(new)
+	if !ok {
+		return false
+	}
+	oldKeys, newKeys := websiteUniqueKeys(oldWebsite), websiteUniqueKeys(newWebsite)
+	if len(oldKeys) != len(newKeys) {
+		return true
+	}
+	for i := range oldKeys {
+		if oldKeys[i] != newKeys[i] {
+			return true
+		}
+	}
+	return false
+}
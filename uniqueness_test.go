@@ -0,0 +1,270 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+// newUniquenessController returns a Controller whose Website and
+// ClusterWebsite indexers hold objs, with the uniqueness indexes.
+func newUniquenessController(t *testing.T, objs ...interface{}) *Controller {
+	t.Helper()
+	uniqueIndexers := cache.Indexers{
+		websiteNameIndex:    uniqueIndexFunc(websiteNameIndex),
+		deploymentNameIndex: uniqueIndexFunc(deploymentNameIndex),
+		serviceNameIndex:    uniqueIndexFunc(serviceNameIndex),
+		hostIndex:           uniqueIndexFunc(hostIndex),
+	}
+	c := &Controller{
+		websitesIndexer:        cache.NewIndexer(cache.MetaNamespaceKeyFunc, uniqueIndexers),
+		clusterWebsitesIndexer: cache.NewIndexer(cache.MetaNamespaceKeyFunc, uniqueIndexers),
+		workqueue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Websites"),
+		recorder:               websiteEventRecorder{record.NewFakeRecorder(10)},
+	}
+	for _, obj := range objs {
+		indexer := c.websitesIndexer
+		if _, ok := obj.(*myv1alpha1.ClusterWebsite); ok {
+			indexer = c.clusterWebsitesIndexer
+		}
+		if err := indexer.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+func TestSyncConflicts(t *testing.T) {
+	older := metav1.NewTime(time.Now().Add(-time.Hour))
+	later := metav1.Now()
+	website := func(namespace, name, deploymentName, host string, created metav1.Time) *myv1alpha1.Website {
+		website := &myv1alpha1.Website{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, CreationTimestamp: created},
+			Spec:       myv1alpha1.WebsiteSpec{DeploymentName: deploymentName},
+		}
+		if host != "" {
+			website.Spec.Ingress = &myv1alpha1.WebsiteIngress{Host: host}
+		}
+		return website
+	}
+	clusterWebsite := func(name, deploymentName, host string, created metav1.Time) *myv1alpha1.ClusterWebsite {
+		view := website("", name, deploymentName, host, created)
+		return &myv1alpha1.ClusterWebsite{
+			ObjectMeta: view.ObjectMeta,
+			Spec:       myv1alpha1.ClusterWebsiteSpec{TargetNamespace: "default", WebsiteSpec: view.Spec},
+		}
+	}
+
+	tests := []struct {
+		name string
+		objs []interface{}
+		// the websites synced and the conflicts each of them reports
+		synced []*myv1alpha1.Website
+		want   [][]string
+	}{
+		{
+			name: "older website keeps the deployment",
+			objs: []interface{}{
+				website("default", "kubia", "kubia", "", older),
+				website("default", "docs", "kubia", "", later),
+			},
+			synced: []*myv1alpha1.Website{
+				website("default", "kubia", "kubia", "", older),
+				website("default", "docs", "kubia", "", later),
+			},
+			want: [][]string{nil, {`deployment "kubia" is used by Website default/kubia`, `service "kubia-npsvc" is used by Website default/kubia`}},
+		},
+		{
+			name: "host across namespaces",
+			objs: []interface{}{
+				website("default", "kubia", "kubia", "Kubia.example.com", later),
+				website("web", "kubia", "kubia", "kubia.example.com", older),
+			},
+			synced: []*myv1alpha1.Website{
+				website("default", "kubia", "kubia", "Kubia.example.com", later),
+				website("web", "kubia", "kubia", "kubia.example.com", older),
+			},
+			want: [][]string{{`host "kubia.example.com" is used by Website web/kubia`}, nil},
+		},
+		{
+			name: "older ClusterWebsite keeps the host",
+			objs: []interface{}{
+				website("web", "kubia", "kubia", "kubia.example.com", later),
+				clusterWebsite("docs", "docs", "kubia.example.com", older),
+			},
+			synced: []*myv1alpha1.Website{
+				website("web", "kubia", "kubia", "kubia.example.com", later),
+				clusterWebsiteView(clusterWebsite("docs", "docs", "kubia.example.com", older)),
+			},
+			want: [][]string{{`host "kubia.example.com" is used by ClusterWebsite docs`}, nil},
+		},
+		{
+			name: "older Website keeps the deployment",
+			objs: []interface{}{
+				website("default", "kubia", "kubia", "", older),
+				clusterWebsite("docs", "kubia", "", later),
+			},
+			synced: []*myv1alpha1.Website{
+				website("default", "kubia", "kubia", "", older),
+				clusterWebsiteView(clusterWebsite("docs", "kubia", "", later)),
+			},
+			want: [][]string{nil, {`deployment "kubia" is used by Website default/kubia`, `service "kubia-npsvc" is used by Website default/kubia`}},
+		},
+		{
+			name: "released name",
+			objs: []interface{}{
+				website("default", "docs", "kubia", "", later),
+			},
+			synced: []*myv1alpha1.Website{website("default", "docs", "kubia", "", later)},
+			want:   [][]string{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newUniquenessController(t, tt.objs...)
+			for i, website := range tt.synced {
+				status := &myv1alpha1.WebsiteStatus{}
+				got, err := c.syncConflicts(website, status)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.Join(got, "; ") != strings.Join(tt.want[i], "; ") {
+					t.Errorf("conflicts of %s = %q, want %q", websiteDescription(website), got, tt.want[i])
+				}
+				if cond := getWebsiteCondition(*status, myv1alpha1.WebsiteConflict); (cond != nil) != (len(tt.want[i]) > 0) {
+					t.Errorf("Conflict condition of %s = %+v, want it set %v", websiteDescription(website), cond, len(tt.want[i]) > 0)
+				}
+			}
+		})
+	}
+}
+
+func TestAdmitUnique(t *testing.T) {
+	held := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia", CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))},
+		Spec:       myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Ingress: &myv1alpha1.WebsiteIngress{Host: "kubia.example.com"}},
+	}
+	// a website created before the names were checked, sharing the host
+	sharing := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "docs", CreationTimestamp: metav1.Now()},
+		Spec:       myv1alpha1.WebsiteSpec{DeploymentName: "docs", Ingress: &myv1alpha1.WebsiteIngress{Host: "kubia.example.com"}},
+	}
+	c := newUniquenessController(t, held, sharing)
+	withSpec := func(website *myv1alpha1.Website, mutate func(*myv1alpha1.WebsiteSpec)) *myv1alpha1.Website {
+		website = website.DeepCopy()
+		mutate(&website.Spec)
+		return website
+	}
+
+	tests := []struct {
+		name    string
+		website *myv1alpha1.Website
+		old     *myv1alpha1.Website
+		wantErr string
+	}{
+		{
+			name:    "create taking a deployment",
+			website: withSpec(sharing, func(spec *myv1alpha1.WebsiteSpec) { spec.DeploymentName = "kubia"; spec.Ingress = nil }),
+			wantErr: `deployment "kubia" is used by Website default/kubia`,
+		},
+		{
+			name:    "create taking a host",
+			website: &myv1alpha1.Website{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "blog"}, Spec: myv1alpha1.WebsiteSpec{DeploymentName: "blog", Ingress: &myv1alpha1.WebsiteIngress{Host: "KUBIA.example.com"}}},
+			wantErr: `host "kubia.example.com" is used by`,
+		},
+		{
+			name:    "create with free names",
+			website: &myv1alpha1.Website{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "kubia"}, Spec: myv1alpha1.WebsiteSpec{DeploymentName: "kubia"}},
+		},
+		{
+			name:    "update keeping a shared name",
+			website: withSpec(sharing, func(spec *myv1alpha1.WebsiteSpec) { spec.Replicas = new(int32) }),
+			old:     sharing,
+		},
+		{
+			name:    "update taking another name",
+			website: withSpec(sharing, func(spec *myv1alpha1.WebsiteSpec) { spec.DeploymentName = "kubia" }),
+			old:     sharing,
+			wantErr: `deployment "kubia" is used by Website default/kubia`,
+		},
+		{
+			name:    "update of the holder",
+			website: withSpec(held, func(spec *myv1alpha1.WebsiteSpec) { spec.Replicas = new(int32) }),
+			old:     held,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.admitUnique(tt.website, tt.old)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("admitUnique() = %v, want it admitted", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("admitUnique() = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnqueueConflictingWebsitesOnDelete(t *testing.T) {
+	deleted := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia"},
+		Spec:       myv1alpha1.WebsiteSpec{DeploymentName: "kubia", Ingress: &myv1alpha1.WebsiteIngress{Host: "kubia.example.com"}},
+	}
+	objs := []interface{}{
+		// waiting for the deployment
+		&myv1alpha1.Website{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "docs"},
+			Spec:       myv1alpha1.WebsiteSpec{DeploymentName: "kubia"},
+		},
+		// waiting for the host
+		&myv1alpha1.ClusterWebsite{
+			ObjectMeta: metav1.ObjectMeta{Name: "portal"},
+			Spec: myv1alpha1.ClusterWebsiteSpec{
+				TargetNamespace: "web",
+				WebsiteSpec:     myv1alpha1.WebsiteSpec{DeploymentName: "portal", Ingress: &myv1alpha1.WebsiteIngress{Host: "kubia.example.com"}},
+			},
+		},
+		// sharing nothing
+		&myv1alpha1.Website{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "kubia"},
+			Spec:       myv1alpha1.WebsiteSpec{DeploymentName: "kubia"},
+		},
+	}
+
+	for _, tt := range []struct {
+		name string
+		obj  interface{}
+	}{
+		{name: "deleted", obj: deleted},
+		{name: "tombstone", obj: cache.DeletedFinalStateUnknown{Key: "default/kubia", Obj: deleted}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newUniquenessController(t, objs...)
+			defer c.workqueue.ShutDown()
+			c.enqueueConflictingWebsites(tt.obj)
+
+			var got []string
+			for c.workqueue.Len() > 0 {
+				key, _ := c.workqueue.Get()
+				got = append(got, key.(string))
+				c.workqueue.Done(key)
+			}
+			sort.Strings(got)
+			if want := []string{"default/docs", "portal"}; strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("enqueued %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+)
+
+const (
+	// gitRepoIndex is the name of the Website and ClusterWebsite informer
+	// index keyed by normalized git repository and branch, see
+	// gitRepoIndexKey.
+	gitRepoIndex = "gitRepo"
+
+	// maxWebhookPayload bounds the size of a push payload we are willing to read.
+	maxWebhookPayload = 5 << 20
+)
+
+// pushEvent is the provider independent view of a git push webhook.
+type pushEvent struct {
+	// Provider is the git host the payload came from: github, gitlab or gitea.
+	Provider string
+	// Branch is the pushed branch, without the refs/heads/ prefix.
+	Branch string
+	// After is the commit SHA the branch points to after the push.
+	After string
+	// RepoURLs are all the URLs the payload advertises for the repository.
+	RepoURLs []string
+}
+
+// pushPayload holds the fields we use from GitHub, GitLab and Gitea push
+// payloads. The three formats overlap enough to share one struct.
+type pushPayload struct {
+	Ref         string `json:"ref"`
+	After       string `json:"after"`
+	CheckoutSHA string `json:"checkout_sha"`
+	Repository  struct {
+		CloneURL   string `json:"clone_url"`
+		GitURL     string `json:"git_url"`
+		SSHURL     string `json:"ssh_url"`
+		HTMLURL    string `json:"html_url"`
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+		Homepage   string `json:"homepage"`
+	} `json:"repository"`
+	Project struct {
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+		WebURL     string `json:"web_url"`
+	} `json:"project"`
+}
+
+// pushTrigger is implemented by the controller to start a sync of a Website
+// after a matching push was received.
+type pushTrigger interface {
+	websitesForPush(repoURL, branch string) ([]*myv1alpha1.Website, error)
+	requestSync(website *myv1alpha1.Website, push *pushEvent) error
+}
+
+// webhookReceiver is an http.Handler accepting push webhooks from GitHub,
+// GitLab and Gitea. It does not talk to the network itself, so it can be
+// exercised with recorded payloads against any pushTrigger.
+type webhookReceiver struct {
+	secret  []byte
+	trigger pushTrigger
+}
+
+func newWebhookReceiver(secret string, trigger pushTrigger) *webhookReceiver {
+	return &webhookReceiver{secret: []byte(secret), trigger: trigger}
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookPayload))
+	if err != nil {
+		http.Error(w, "unable to read payload", http.StatusBadRequest)
+		return
+	}
+
+	provider, event := webhookProvider(req.Header)
+	if provider == "" {
+		http.Error(w, "unknown webhook provider", http.StatusBadRequest)
+		return
+	}
+	if err := r.verify(provider, req.Header, body); err != nil {
+		klog.Warningf("Rejected %s webhook: %v", provider, err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	if !isPushEvent(event) {
+		// ping and other events are acknowledged but not acted upon
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	push, err := parsePushEvent(provider, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if push.Branch == "" {
+		// tag pushes do not move any branch we follow
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	triggered, err := r.dispatch(push)
+	if err != nil {
+		klog.Errorf("Failed to handle %s push to %s: %v", provider, push.Branch, err)
+		http.Error(w, "failed to trigger sync", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "triggered %d website(s)\n", triggered)
+}
+
+// dispatch triggers a sync of every Website and ClusterWebsite following the
+// pushed repository and branch, and returns how many were triggered.
+func (r *webhookReceiver) dispatch(push *pushEvent) (int, error) {
+	seen := map[string]bool{}
+	triggered := 0
+	for _, repoURL := range push.RepoURLs {
+		websites, err := r.trigger.websitesForPush(repoURL, push.Branch)
+		if err != nil {
+			return triggered, err
+		}
+		for _, website := range websites {
+			key, err := websiteKey(website)
+			if err != nil {
+				return triggered, err
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if err := r.trigger.requestSync(website, push); err != nil {
+				return triggered, err
+			}
+			triggered++
+		}
+	}
+	return triggered, nil
+}
+
+// webhookProvider detects the git host from the request headers and returns
+// it along with the event name it sent. Gitea is checked first since it also
+// sends GitHub compatible headers.
+func webhookProvider(header http.Header) (string, string) {
+	if event := header.Get("X-Gitea-Event"); event != "" {
+		return "gitea", event
+	}
+	if event := header.Get("X-Gitlab-Event"); event != "" {
+		return "gitlab", event
+	}
+	if event := header.Get("X-GitHub-Event"); event != "" {
+		return "github", event
+	}
+	return "", ""
+}
+
+func isPushEvent(event string) bool {
+	return event == "push" || event == "Push Hook"
+}
+
+// verify checks the payload against the shared secret. GitHub and Gitea sign
+// the body with HMAC, GitLab only echoes the secret token back.
+func (r *webhookReceiver) verify(provider string, header http.Header, body []byte) error {
+	switch provider {
+	case "github":
+		if sig := header.Get("X-Hub-Signature-256"); sig != "" {
+			return verifyHMAC(sha256.New, r.secret, body, strings.TrimPrefix(sig, "sha256="))
+		}
+		if sig := header.Get("X-Hub-Signature"); sig != "" {
+			return verifyHMAC(sha1.New, r.secret, body, strings.TrimPrefix(sig, "sha1="))
+		}
+		return fmt.Errorf("missing signature header")
+	case "gitea":
+		return verifyHMAC(sha256.New, r.secret, body, header.Get("X-Gitea-Signature"))
+	case "gitlab":
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), r.secret) != 1 {
+			return fmt.Errorf("token mismatch")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown provider %q", provider)
+}
+
+func verifyHMAC(h func() hash.Hash, secret, body []byte, signature string) error {
+	if signature == "" {
+		return fmt.Errorf("missing signature")
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("malformed signature: %v", err)
+	}
+	mac := hmac.New(h, secret)
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// parsePushEvent decodes a push payload of the given provider.
+func parsePushEvent(provider string, body []byte) (*pushEvent, error) {
+	var payload pushPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid %s push payload: %v", provider, err)
+	}
+	push := &pushEvent{
+		Provider: provider,
+		After:    payload.After,
+	}
+	if push.After == "" {
+		push.After = payload.CheckoutSHA
+	}
+	if strings.HasPrefix(payload.Ref, "refs/heads/") {
+		push.Branch = strings.TrimPrefix(payload.Ref, "refs/heads/")
+	}
+	for _, u := range []string{
+		payload.Repository.CloneURL,
+		payload.Repository.GitURL,
+		payload.Repository.SSHURL,
+		payload.Repository.HTMLURL,
+		payload.Repository.GitHTTPURL,
+		payload.Repository.GitSSHURL,
+		payload.Repository.Homepage,
+		payload.Project.GitHTTPURL,
+		payload.Project.GitSSHURL,
+		payload.Project.WebURL,
+	} {
+		if u != "" {
+			push.RepoURLs = append(push.RepoURLs, u)
+		}
+	}
+	if len(push.RepoURLs) == 0 {
+		return nil, fmt.Errorf("%s push payload has no repository url", provider)
+	}
+	return push, nil
+}
+
+// normalizeGitURL reduces the different spellings of a repository URL
+// (https, ssh, scp-like, with or without .git) to host/path.
+func normalizeGitURL(repoURL string) string {
+	u := strings.ToLower(strings.TrimSpace(repoURL))
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	} else if i := strings.Index(u, ":"); i >= 0 && !strings.Contains(u[:i], "/") {
+		// scp-like syntax: git@host:org/repo.git
+		u = u[:i] + "/" + u[i+1:]
+	}
+	if i := strings.Index(u, "@"); i >= 0 && i < strings.Index(u+"/", "/") {
+		u = u[i+1:]
+	}
+	if i := strings.Index(u, "/"); i >= 0 {
+		if j := strings.Index(u[:i], ":"); j >= 0 {
+			// drop the port
+			u = u[:j] + u[i:]
+		}
+	}
+	u = strings.TrimRight(u, "/")
+	return strings.TrimSuffix(u, ".git")
+}
+
+// gitRepoIndexKey returns the gitRepoIndex key for a repository and branch.
+func gitRepoIndexKey(repoURL, branch string) string {
+	return normalizeGitURL(repoURL) + "#" + branch
+}
+
+// indexWebsiteByGitRepo is the cache.IndexFunc for gitRepoIndex.
+func indexWebsiteByGitRepo(obj interface{}) ([]string, error) {
+	website, ok := websiteOf(obj)
+	if !ok || websiteRepo(website) == "" {
+		return nil, nil
+	}
+	return []string{gitRepoIndexKey(websiteRepo(website), websiteBranch(website))}, nil
+}
+
+// websitesForPush returns all Websites following repoURL at branch, and the
+// views of the ClusterWebsites following it.
+func (c *Controller) websitesForPush(repoURL, branch string) ([]*myv1alpha1.Website, error) {
+	var websites []*myv1alpha1.Website
+	for _, indexer := range []cache.Indexer{c.websitesIndexer, c.clusterWebsitesIndexer} {
+		objs, err := indexer.ByIndex(gitRepoIndex, gitRepoIndexKey(repoURL, branch))
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			if website, ok := websiteOf(obj); ok {
+				websites = append(websites, website)
+			}
+		}
+	}
+	return websites, nil
+}
+
+// requestSync records the pushed revision on the Website or ClusterWebsite,
+// which the syncHandler stamps into the pod template to roll out the new
+// content.
+func (c *Controller) requestSync(website *myv1alpha1.Website, push *pushEvent) error {
+	if website.Annotations[syncRequestAnnotation] == push.After {
+		return nil
+	}
+	if isClusterWebsite(website) {
+		clusterWebsite, err := c.clusterWebsitesLister.Get(website.Name)
+		if err != nil {
+			return err
+		}
+		clusterWebsiteCopy := clusterWebsite.DeepCopy()
+		if clusterWebsiteCopy.Annotations == nil {
+			clusterWebsiteCopy.Annotations = map[string]string{}
+		}
+		clusterWebsiteCopy.Annotations[syncRequestAnnotation] = push.After
+		if _, err := c.sampleclientset.MycontrollerV1alpha1().ClusterWebsites().Update(clusterWebsiteCopy); err != nil {
+			return err
+		}
+	} else {
+		websiteCopy := website.DeepCopy()
+		if websiteCopy.Annotations == nil {
+			websiteCopy.Annotations = map[string]string{}
+		}
+		websiteCopy.Annotations[syncRequestAnnotation] = push.After
+		if _, err := c.sampleclientset.MycontrollerV1alpha1().Websites(website.Namespace).Update(websiteCopy); err != nil {
+			return err
+		}
+	}
+	key, _ := websiteKey(website)
+	klog.Infof("Push of %s to %s received from %s, syncing '%s'", push.After, push.Branch, push.Provider, key)
+	c.recorder.Eventf(website, corev1.EventTypeNormal, SyncRequested, MessageSyncRequested, push.Branch, push.After, push.Provider)
+	return nil
+}
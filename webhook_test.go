@@ -0,0 +1,247 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	myv1alpha1 "github.com/nevermosby/my-crd-controller/pkg/apis/mycontroller/v1alpha1"
+	"github.com/nevermosby/my-crd-controller/pkg/client/clientset/versioned/fake"
+	listers "github.com/nevermosby/my-crd-controller/pkg/client/listers/mycontroller/v1alpha1"
+)
+
+const testWebhookSecret = "s3cret"
+
+// fakeTrigger serves the websites following one repository and records the
+// syncs requested.
+type fakeTrigger struct {
+	repo      string
+	branch    string
+	websites  []*myv1alpha1.Website
+	requested []string
+}
+
+func (f *fakeTrigger) websitesForPush(repoURL, branch string) ([]*myv1alpha1.Website, error) {
+	if gitRepoIndexKey(repoURL, branch) != gitRepoIndexKey(f.repo, f.branch) {
+		return nil, nil
+	}
+	return f.websites, nil
+}
+
+func (f *fakeTrigger) requestSync(website *myv1alpha1.Website, push *pushEvent) error {
+	f.requested = append(f.requested, website.Namespace+"/"+website.Name+"@"+push.After)
+	return nil
+}
+
+func sign(h func() hash.Hash, secret string, body []byte) string {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookReceiver(t *testing.T) {
+	payload := func(name string) []byte {
+		body, err := ioutil.ReadFile(filepath.Join("artifacts", "webhooks", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+	github, gitlab, gitea := payload("github-push.json"), payload("gitlab-push.json"), payload("gitea-push.json")
+
+	tests := []struct {
+		name   string
+		body   []byte
+		header map[string]string
+		repo   string
+		want   int
+		synced []string
+	}{
+		{
+			name:   "github sha256",
+			body:   github,
+			header: map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(sha256.New, testWebhookSecret, github)},
+			repo:   "git@github.com:nevermosby/kubia-website-example.git",
+			want:   http.StatusAccepted,
+			synced: []string{"default/kubia@0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c", "web/docs@0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c"},
+		},
+		{
+			name:   "github sha1",
+			body:   github,
+			header: map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature": "sha1=" + sign(sha1.New, testWebhookSecret, github)},
+			repo:   "https://github.com/nevermosby/kubia-website-example",
+			want:   http.StatusAccepted,
+			synced: []string{"default/kubia@0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c", "web/docs@0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c"},
+		},
+		{
+			name:   "github bad signature",
+			body:   github,
+			header: map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(sha256.New, "other", github)},
+			repo:   "https://github.com/nevermosby/kubia-website-example.git",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "github unsigned",
+			body:   github,
+			header: map[string]string{"X-GitHub-Event": "push"},
+			repo:   "https://github.com/nevermosby/kubia-website-example.git",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "github ping",
+			body:   []byte(`{"zen":"Design for failure."}`),
+			header: map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": "sha256=" + sign(sha256.New, testWebhookSecret, []byte(`{"zen":"Design for failure."}`))},
+			repo:   "https://github.com/nevermosby/kubia-website-example.git",
+			want:   http.StatusNoContent,
+		},
+		{
+			name:   "gitlab token",
+			body:   gitlab,
+			header: map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": testWebhookSecret},
+			repo:   "https://gitlab.com/nevermosby/kubia-website-example.git",
+			want:   http.StatusAccepted,
+			synced: []string{"default/kubia@da1560886d4f094c3e6c9ef40349f7d38b5d27d7", "web/docs@da1560886d4f094c3e6c9ef40349f7d38b5d27d7"},
+		},
+		{
+			name:   "gitlab bad token",
+			body:   gitlab,
+			header: map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "other"},
+			repo:   "https://gitlab.com/nevermosby/kubia-website-example.git",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name: "gitea",
+			body: gitea,
+			// Gitea also sends the GitHub headers
+			header: map[string]string{"X-Gitea-Event": "push", "X-GitHub-Event": "push", "X-Gitea-Signature": sign(sha256.New, testWebhookSecret, gitea)},
+			repo:   "https://gitea.example.com/nevermosby/kubia-website-example.git",
+			want:   http.StatusAccepted,
+			synced: []string{"default/kubia@bffeb74224043ba2feb48d137756c8a9331c449a", "web/docs@bffeb74224043ba2feb48d137756c8a9331c449a"},
+		},
+		{
+			name:   "gitea bad signature",
+			body:   gitea,
+			header: map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign(sha256.New, testWebhookSecret, github)},
+			repo:   "https://gitea.example.com/nevermosby/kubia-website-example.git",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "other repository",
+			body:   github,
+			header: map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(sha256.New, testWebhookSecret, github)},
+			repo:   "https://github.com/nevermosby/other.git",
+			want:   http.StatusAccepted,
+		},
+		{
+			name: "unknown provider",
+			body: github,
+			repo: "https://github.com/nevermosby/kubia-website-example.git",
+			want: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := &fakeTrigger{
+				repo:   tt.repo,
+				branch: "master",
+				websites: []*myv1alpha1.Website{
+					{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia"}},
+					{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "docs"}},
+				},
+			}
+			req := httptest.NewRequest(http.MethodPost, "/hooks/git", strings.NewReader(string(tt.body)))
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			newWebhookReceiver(testWebhookSecret, trigger).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if !reflect.DeepEqual(trigger.requested, tt.synced) {
+				t.Errorf("synced %v, want %v", trigger.requested, tt.synced)
+			}
+		})
+	}
+}
+
+func TestPushSyncsWebsitesAndClusterWebsites(t *testing.T) {
+	const repo = "https://github.com/nevermosby/kubia-website-example.git"
+	website := &myv1alpha1.Website{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubia"},
+		Spec:       myv1alpha1.WebsiteSpec{GitRepo: repo},
+	}
+	// the same name in the target namespace, told apart by its kind
+	clusterWebsite := &myv1alpha1.ClusterWebsite{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubia"},
+		Spec:       myv1alpha1.ClusterWebsiteSpec{TargetNamespace: "default", WebsiteSpec: myv1alpha1.WebsiteSpec{GitRepo: "git@github.com:nevermosby/kubia-website-example.git"}},
+	}
+	other := &myv1alpha1.ClusterWebsite{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Spec:       myv1alpha1.ClusterWebsiteSpec{TargetNamespace: "default", WebsiteSpec: myv1alpha1.WebsiteSpec{GitRepo: repo, Branch: "gh-pages"}},
+	}
+	indexers := cache.Indexers{gitRepoIndex: indexWebsiteByGitRepo}
+	websites := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)
+	clusterWebsites := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)
+	if err := websites.Add(website); err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range []*myv1alpha1.ClusterWebsite{clusterWebsite, other} {
+		if err := clusterWebsites.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := fake.NewSimpleClientset(website, clusterWebsite, other)
+	c := &Controller{
+		sampleclientset:        client,
+		websitesIndexer:        websites,
+		clusterWebsitesIndexer: clusterWebsites,
+		clusterWebsitesLister:  listers.NewClusterWebsiteLister(clusterWebsites),
+		recorder:               websiteEventRecorder{record.NewFakeRecorder(10)},
+	}
+
+	body, err := ioutil.ReadFile(filepath.Join("artifacts", "webhooks", "github-push.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/hooks/git", strings.NewReader(string(body)))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+sign(sha256.New, testWebhookSecret, body))
+	rec := httptest.NewRecorder()
+	newWebhookReceiver(testWebhookSecret, c).ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), "triggered 2 website(s)") {
+		t.Fatalf("response %d %q, want 2 websites triggered", rec.Code, rec.Body.String())
+	}
+
+	const after = "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c"
+	gotWebsite, err := client.MycontrollerV1alpha1().Websites("default").Get("kubia", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gotWebsite.Annotations[syncRequestAnnotation]; got != after {
+		t.Errorf("Website %s = %q, want %q", syncRequestAnnotation, got, after)
+	}
+	for name, want := range map[string]string{"kubia": after, "other": ""} {
+		got, err := client.MycontrollerV1alpha1().ClusterWebsites().Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Annotations[syncRequestAnnotation] != want {
+			t.Errorf("ClusterWebsite %s %s = %q, want %q", name, syncRequestAnnotation, got.Annotations[syncRequestAnnotation], want)
+		}
+	}
+}